@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vladComan0/go-snippets/internal/highlight"
+	"github.com/vladComan0/go-snippets/internal/models"
+	"github.com/vladComan0/go-snippets/internal/validator"
+)
+
+func (app *application) apiSnippetList(w http.ResponseWriter, r *http.Request) {
+	var v validator.Validator
+	filters := app.readFilters(r.URL.Query(), 20, &v)
+	if !v.Valid() {
+		app.apiFailedValidation(w, v.FieldErrors)
+		return
+	}
+
+	snippets, metadata, err := app.snippets.Latest(r.Context(), filters)
+	if err != nil {
+		app.apiServerError(w, r, err)
+		return
+	}
+
+	for i, snippet := range snippets {
+		snippets[i] = app.withoutSlug(r, snippet)
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"snippets": snippets, "metadata": metadata}, nil); err != nil {
+		app.apiServerError(w, r, err)
+	}
+}
+
+func (app *application) apiSnippetGet(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.apiReadableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	js, err := app.encodeJSON(envelope{"snippet": app.withoutSlug(r, snippet)})
+	if err != nil {
+		app.apiServerError(w, r, err)
+		return
+	}
+
+	if err := app.burnRead(r, snippet); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.apiClientError(w, http.StatusNotFound)
+		default:
+			app.apiServerError(w, r, err)
+		}
+		return
+	}
+	app.metrics.snippetsViewed.WithLabelValues("api").Inc()
+
+	if err := app.writeEncodedJSON(w, http.StatusOK, js, nil); err != nil {
+		app.apiServerError(w, r, err)
+	}
+}
+
+func (app *application) apiSnippetCreate(w http.ResponseWriter, r *http.Request) {
+	var form snippetCreateForm
+
+	if err := app.readJSON(w, r, &form); err != nil {
+		app.apiBadRequest(w, err)
+		return
+	}
+
+	// The languages and visibility are optional for API clients, unlike in the
+	// HTML form where they always have a selected value.
+	for i := range form.Files {
+		if form.Files[i].Language == "" {
+			form.Files[i].Language = highlight.PlainText
+		}
+	}
+	if form.Visibility == "" {
+		form.Visibility = models.VisibilityPublic
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		app.apiFailedValidation(w, form.FieldErrors)
+		return
+	}
+
+	snippet := &models.Snippet{
+		UserID:     app.authenticatedUserID(r),
+		Title:      form.Title,
+		Files:      toFiles(form.Files),
+		Visibility: form.Visibility,
+		Tags:       parseTags(string(form.Tags)),
+		Expires:    form.expiry,
+
+		BurnAfterReading: form.BurnAfterReading,
+	}
+	if err := app.snippets.Insert(r.Context(), snippet); err != nil {
+		app.apiServerError(w, r, err)
+		return
+	}
+	app.metrics.snippetsCreated.WithLabelValues("api").Inc()
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/api/v1/snippets/%s", snippet.Ref()))
+
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"id": snippet.ID, "slug": snippet.Slug}, headers); err != nil {
+		app.apiServerError(w, r, err)
+	}
+}
+
+func (app *application) apiSnippetUpdate(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.apiEditableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// Omitted fields keep their current value: the form is filled in with the
+	// snippet, and the body only overwrites the fields it contains. The files
+	// are filled in afterwards instead, as the files of the body would
+	// otherwise be merged into the current ones.
+	form := snippetEditForm{
+		Title:      snippet.Title,
+		Visibility: snippet.Visibility,
+		Tags:       tagList(strings.Join(snippet.Tags, ",")),
+	}
+
+	if err := app.readJSON(w, r, &form); err != nil {
+		app.apiBadRequest(w, err)
+		return
+	}
+
+	if form.Files == nil {
+		form.Files = fileForms(snippet.Files)
+	}
+	for i := range form.Files {
+		if form.Files[i].Language == "" {
+			form.Files[i].Language = highlight.PlainText
+		}
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		app.apiFailedValidation(w, form.FieldErrors)
+		return
+	}
+
+	updated := *snippet
+	updated.Title = form.Title
+	updated.Files = toFiles(form.Files)
+	updated.Visibility = form.Visibility
+	updated.Tags = parseTags(string(form.Tags))
+	if err := app.snippets.Update(r.Context(), &updated, app.authenticatedUserID(r)); err != nil {
+		app.apiServerError(w, r, err)
+		return
+	}
+
+	snippet, err := app.snippets.Get(r.Context(), snippet.ID)
+	if err != nil {
+		app.apiServerError(w, r, err)
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"snippet": snippet}, nil); err != nil {
+		app.apiServerError(w, r, err)
+	}
+}
+
+func (app *application) apiSnippetDelete(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.apiOwnedSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.snippets.Delete(r.Context(), snippet.ID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.apiClientError(w, http.StatusNotFound)
+		default:
+			app.apiServerError(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (app *application) apiAccountView(w http.ResponseWriter, r *http.Request) {
+	user, err := app.users.Get(r.Context(), app.authenticatedUserID(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.apiClientError(w, http.StatusUnauthorized)
+		default:
+			app.apiServerError(w, r, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil); err != nil {
+		app.apiServerError(w, r, err)
+	}
+}
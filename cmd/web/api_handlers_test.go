@@ -0,0 +1,564 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+	"github.com/vladComan0/go-snippets/internal/models"
+	"github.com/vladComan0/go-snippets/internal/models/mocks"
+)
+
+func TestAPISnippetGet(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+		{
+			name:     "Tags",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"nature"`,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			wantCode: http.StatusNotFound,
+			wantBody: `"status": 404`,
+		},
+		{
+			name:     "Unlisted snippet by slug",
+			urlPath:  "/api/v1/snippets/" + mocks.MockUnlistedSlug,
+			wantCode: http.StatusOK,
+			wantBody: `"visibility": "unlisted"`,
+		},
+		{
+			name:     "Unlisted snippet by ID",
+			urlPath:  "/api/v1/snippets/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private snippet",
+			urlPath:  "/api/v1/snippets/5",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String ID",
+			urlPath:  "/api/v1/snippets/foo",
+			wantCode: http.StatusNotFound,
+			wantBody: `"message": "not found"`,
+		},
+		{
+			name:     "Unknown endpoint",
+			urlPath:  "/api/v1/foo",
+			wantCode: http.StatusNotFound,
+			wantBody: `"error"`,
+		},
+		{
+			name:     "List",
+			urlPath:  "/api/v1/snippets",
+			wantCode: http.StatusOK,
+			wantBody: `"total_records": 1`,
+		},
+		{
+			name:     "List with invalid page",
+			urlPath:  "/api/v1/snippets?page=0",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"page": "This field must be between 1 and 10 million."`,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			assert.Equal(t, headers.Get("Content-Type"), "application/json")
+			assert.StringContains(t, body, subtest.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetSlugHidden(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	// Only the owner sees the slug of a public snippet.
+	for _, urlPath := range []string{"/api/v1/snippets", "/api/v1/snippets/1"} {
+		_, _, body := ts.get(t, urlPath)
+		assert.Equal(t, strings.Contains(body, `"slug"`), false)
+
+		_, _, body = ts.sendJSONWithToken(t, http.MethodGet, urlPath, mocks.MockToken, "")
+		assert.StringContains(t, body, `"slug": "fa6mQ2ZcLm1ZxQbO"`)
+	}
+}
+
+func TestAPISnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	const validBody = `{"title": "O snail", "files": [{"name": "fuji.txt", "content": "Climb Mount Fuji"}], "expires": "7d"}`
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, _, body := ts.sendJSON(t, http.MethodPost, "/api/v1/snippets", validBody)
+
+		assert.Equal(t, code, http.StatusUnauthorized)
+		assert.StringContains(t, body, `"status": 401`)
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid body",
+			body:     validBody,
+			wantCode: http.StatusCreated,
+			wantBody: `"id": 2`,
+		},
+		{
+			name:     "Invalid fields",
+			body:     `{"title": "", "files": [{"content": "Climb Mount Fuji"}], "expires": "3"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires": "This field must be one of the listed expiry options."`,
+		},
+		{
+			name:     "Tags",
+			body:     `{"title": "O snail", "files": [{"content": "Climb Mount Fuji"}], "tags": ["haiku", "Issa"], "expires": "7d"}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"id": 2`,
+		},
+		{
+			name:     "Invalid tags",
+			body:     `{"title": "O snail", "files": [{"content": "Climb Mount Fuji"}], "tags": ["no spaces"], "expires": "7d"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"tags": "Tags can only contain letters, digits and the characters . + _ -"`,
+		},
+		{
+			name:     "Never expires",
+			body:     `{"title": "O snail", "files": [{"content": "Climb Mount Fuji"}], "expires": "never", "burn_after_reading": true}`,
+			wantCode: http.StatusCreated,
+			wantBody: `"id": 2`,
+		},
+		{
+			name:     "Invalid custom expiry",
+			body:     `{"title": "O snail", "files": [{"content": "Climb Mount Fuji"}], "expires": "custom", "expires_at": "tomorrow"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires_at": "This field must be a valid date and time."`,
+		},
+		{
+			name:     "No files",
+			body:     `{"title": "O snail", "files": [], "expires": "7d"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"files": "A snippet must have at least one file."`,
+		},
+		{
+			name:     "Duplicate file names",
+			body:     `{"title": "O snail", "files": [{"name": "a.txt", "content": "x"}, {"name": "a.txt", "content": "y"}], "expires": "7d"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"files[1].name": "Every file of a snippet must have a different name."`,
+		},
+		{
+			name:     "Unknown field",
+			body:     `{"title": "O snail", "files": [{"content": "Climb Mount Fuji"}], "expires": "7d", "id": 5}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `body contains unknown key \"id\"`,
+		},
+		{
+			name:     "Badly-formed JSON",
+			body:     `{"title": "O snail",`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `badly-formed JSON`,
+		},
+		{
+			name:     "Empty body",
+			wantCode: http.StatusBadRequest,
+			wantBody: `application/json`,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.sendJSON(t, http.MethodPost, "/api/v1/snippets", subtest.body)
+
+			assert.Equal(t, code, subtest.wantCode)
+			assert.StringContains(t, body, subtest.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetUpdateDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		method   string
+		urlPath  string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "Update own snippet",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title": "A new title", "files": [{"name": "new.txt", "content": "Some new content"}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Update title only",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title": "A new title"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Update with blank content",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title": "A new title", "files": [{"name": "new.txt", "content": " "}]}`,
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Update someone else's snippet",
+			method:   http.MethodPut,
+			urlPath:  "/api/v1/snippets/3",
+			body:     `{"title": "A new title", "files": [{"content": "Some new content"}]}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Delete someone else's snippet",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/snippets/3",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Delete someone else's unlisted snippet",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/snippets/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Delete non-existent snippet",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/snippets/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Delete own snippet",
+			method:   http.MethodDelete,
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusNoContent,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, _ := ts.sendJSON(t, subtest.method, subtest.urlPath, subtest.body)
+
+			assert.Equal(t, code, subtest.wantCode)
+		})
+	}
+}
+
+// updatingSnippets is a snippet model which records the last update.
+type updatingSnippets struct {
+	mocks.SnippetModel
+	updated *models.Snippet
+}
+
+func (m *updatingSnippets) Update(ctx context.Context, snippet *models.Snippet, authorID int) error {
+	m.updated = snippet
+	return m.SnippetModel.Update(ctx, snippet, authorID)
+}
+
+func TestAPISnippetPartialUpdate(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantCode       int
+		wantTitle      string
+		wantFiles      int
+		wantVisibility string
+		wantTags       string
+		wantBody       string
+	}{
+		{
+			name:           "Title only",
+			body:           `{"title": "A new title"}`,
+			wantCode:       http.StatusOK,
+			wantTitle:      "A new title",
+			wantFiles:      2,
+			wantVisibility: models.VisibilityPublic,
+			wantTags:       "haiku,nature",
+		},
+		{
+			name:           "Files only",
+			body:           `{"files": [{"name": "new.txt", "content": "Some new content"}]}`,
+			wantCode:       http.StatusOK,
+			wantTitle:      "An old silent pond",
+			wantFiles:      1,
+			wantVisibility: models.VisibilityPublic,
+			wantTags:       "haiku,nature",
+		},
+		{
+			name:           "Tags only",
+			body:           `{"tags": ["Go", "http"], "visibility": "unlisted"}`,
+			wantCode:       http.StatusOK,
+			wantTitle:      "An old silent pond",
+			wantFiles:      2,
+			wantVisibility: models.VisibilityUnlisted,
+			wantTags:       "go,http",
+		},
+		{
+			name:           "Cleared tags",
+			body:           `{"tags": []}`,
+			wantCode:       http.StatusOK,
+			wantTitle:      "An old silent pond",
+			wantFiles:      2,
+			wantVisibility: models.VisibilityPublic,
+			wantTags:       "",
+		},
+		{
+			name:     "Invalid tags",
+			body:     `{"tags": ["a", "b", "c", "d", "e", "f"]}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"tags": "This field cannot contain more than 5 tags."`,
+		},
+		{
+			name:     "Tags as a string",
+			body:     `{"tags": "go, http"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Blank title",
+			body:     `{"title": ""}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"title": "This field cannot be blank."`,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			snippets := &updatingSnippets{}
+			app.snippets = snippets
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t)
+
+			code, _, body := ts.sendJSON(t, http.MethodPut, "/api/v1/snippets/1", subtest.body)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+			if code != http.StatusOK {
+				assert.Equal(t, snippets.updated == nil, true)
+				return
+			}
+			assert.Equal(t, snippets.updated.Title, subtest.wantTitle)
+			assert.Equal(t, len(snippets.updated.Files), subtest.wantFiles)
+			assert.Equal(t, snippets.updated.Visibility, subtest.wantVisibility)
+			assert.Equal(t, strings.Join(snippets.updated.Tags, ","), subtest.wantTags)
+		})
+	}
+}
+
+func TestAPIAccountView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, _ := ts.get(t, "/api/v1/account")
+	assert.Equal(t, code, http.StatusUnauthorized)
+
+	ts.login(t)
+
+	code, _, body := ts.get(t, "/api/v1/account")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, `"email": "alice@example.com"`)
+}
+
+func TestAPITokenAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	const validBody = `{"title": "O snail", "files": [{"name": "fuji.txt", "content": "Climb Mount Fuji"}], "expires": "7d"}`
+
+	tests := []struct {
+		name     string
+		method   string
+		urlPath  string
+		token    string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid token",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/account",
+			token:    mocks.MockToken,
+			wantCode: http.StatusOK,
+			wantBody: `"email": "alice@example.com"`,
+		},
+		{
+			name:     "Valid token with write scope",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/snippets",
+			token:    mocks.MockToken,
+			body:     validBody,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:     "Missing scope",
+			method:   http.MethodPost,
+			urlPath:  "/api/v1/snippets",
+			token:    mocks.MockReadOnlyToken,
+			body:     validBody,
+			wantCode: http.StatusForbidden,
+			wantBody: `missing the \"snippets:write\" scope`,
+		},
+		{
+			name:     "Private snippet with read scope",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/snippets/5",
+			token:    mocks.MockToken,
+			wantCode: http.StatusOK,
+			wantBody: `"visibility": "private"`,
+		},
+		{
+			name:     "Private snippet without read scope",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/snippets/5",
+			token:    mocks.MockReadOnlyToken,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unknown token",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/account",
+			token:    "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Malformed token",
+			method:   http.MethodGet,
+			urlPath:  "/api/v1/snippets",
+			token:    "foo",
+			wantCode: http.StatusUnauthorized,
+			wantBody: "invalid or expired authentication token",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.sendJSONWithToken(t, subtest.method, subtest.urlPath, subtest.token, subtest.body)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+}
+
+func TestTokensRefusedOutsideAPI(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	// Fetch a CSRF token anonymously, as a client holding only an API token could.
+	_, _, body := ts.get(t, "/user/login")
+	csrfToken := extractCSRFToken(t, body)
+
+	tests := []struct {
+		name          string
+		method        string
+		urlPath       string
+		authorization string
+		form          url.Values
+		wantCode      int
+	}{
+		{
+			name:          "Create a token",
+			method:        http.MethodPost,
+			urlPath:       "/account/tokens",
+			authorization: "Bearer " + mocks.MockToken,
+			form:          url.Values{"name": {"Escalated"}, "scopes": {models.ScopeSnippetsWrite}, "expires": {"30d"}},
+			wantCode:      http.StatusSeeOther,
+		},
+		{
+			name:          "Delete a snippet",
+			method:        http.MethodPost,
+			urlPath:       "/snippet/delete/1",
+			authorization: "Bearer " + mocks.MockToken,
+			wantCode:      http.StatusSeeOther,
+		},
+		{
+			name:          "Basic authentication",
+			method:        http.MethodGet,
+			urlPath:       "/",
+			authorization: "Basic dXNlcjpwYXNz",
+			wantCode:      http.StatusOK,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{"csrf_token": {csrfToken}}
+			for key, values := range subtest.form {
+				form[key] = values
+			}
+
+			request, err := http.NewRequest(subtest.method, ts.URL+subtest.urlPath, strings.NewReader(form.Encode()))
+			assert.NilError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			request.Header.Set("Authorization", subtest.authorization)
+
+			code, headers, _ := ts.do(t, request)
+
+			// The token is ignored, so the request is anonymous and the
+			// protected routes redirect to the login page.
+			assert.Equal(t, code, subtest.wantCode)
+			if code == http.StatusSeeOther {
+				assert.Equal(t, headers.Get("Location"), "/user/login")
+			}
+		})
+	}
+}
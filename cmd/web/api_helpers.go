@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/vladComan0/go-snippets/internal/models"
+)
+
+// envelope wraps every JSON response body in a named top-level object, e.g.
+// {"snippet": {...}} or {"error": {...}}.
+type envelope map[string]any
+
+// apiError is the structure of the "error" object returned by every API
+// endpoint when a request cannot be completed.
+type apiError struct {
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope, headers http.Header) error {
+	js, err := app.encodeJSON(data)
+	if err != nil {
+		return err
+	}
+
+	return app.writeEncodedJSON(w, status, js, headers)
+}
+
+// encodeJSON encodes data as the body of a JSON response.
+func (app *application) encodeJSON(data envelope) ([]byte, error) {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(js, '\n'), nil
+}
+
+// writeEncodedJSON sends a JSON response whose body was encoded by encodeJSON.
+func (app *application) writeEncodedJSON(w http.ResponseWriter, status int, js []byte, headers http.Header) error {
+	for key, value := range headers {
+		w.Header()[key] = value
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err := w.Write(js)
+
+	return err
+}
+
+// readJSON decodes a single JSON value from the request body into dst. Requests
+// must carry an "application/json" Content-Type, which also stops plain HTML
+// forms on other sites from submitting to the API with the user's cookies.
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errors.New("the Content-Type header must be application/json")
+	}
+
+	const maxBytes = 1_048_576
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var (
+			syntaxError        *json.SyntaxError
+			unmarshalTypeError *json.UnmarshalTypeError
+			invalidUnmarshal   *json.InvalidUnmarshalError
+			maxBytesError      *http.MaxBytesError
+		)
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+			return fmt.Errorf("body contains unknown key %s", fieldName)
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		case errors.As(err, &invalidUnmarshal):
+			panic(err)
+		default:
+			return err
+		}
+	}
+
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+func (app *application) apiErrorResponse(w http.ResponseWriter, status int, message string, fields map[string]string) {
+	data := envelope{"error": apiError{
+		Status:  status,
+		Message: message,
+		Fields:  fields,
+	}}
+
+	if err := app.writeJSON(w, status, data, nil); err != nil {
+		app.logger.Error("unable to write JSON error response", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (app *application) apiServerError(w http.ResponseWriter, r *http.Request, err error) {
+	if app.contextError(w, r, err, app.apiClientError) {
+		return
+	}
+
+	trace := string(debug.Stack())
+
+	app.logger.Error(err.Error(), "request_id", app.requestID(r), "method", r.Method, "uri", r.URL.RequestURI(), "trace", trace)
+
+	message := "the server encountered a problem and could not process your request"
+	if app.debugEnabled {
+		message = fmt.Sprintf("%s\n%s", err.Error(), trace)
+	}
+	app.apiErrorResponse(w, http.StatusInternalServerError, message, nil)
+}
+
+func (app *application) apiClientError(w http.ResponseWriter, status int) {
+	app.apiErrorResponse(w, status, strings.ToLower(http.StatusText(status)), nil)
+}
+
+func (app *application) invalidAuthenticationToken(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	app.apiErrorResponse(w, http.StatusUnauthorized, "invalid or expired authentication token", nil)
+}
+
+func (app *application) apiBadRequest(w http.ResponseWriter, err error) {
+	app.apiErrorResponse(w, http.StatusBadRequest, err.Error(), nil)
+}
+
+func (app *application) apiFailedValidation(w http.ResponseWriter, fields map[string]string) {
+	app.apiErrorResponse(w, http.StatusUnprocessableEntity, "the request contains invalid fields", fields)
+}
+
+// apiReadableSnippet is the JSON counterpart of readableSnippet.
+func (app *application) apiReadableSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, err := app.readSnippet(r)
+	if err != nil {
+		switch {
+		case errors.Is(err, errInvalidID), errors.Is(err, models.ErrNoRecord):
+			app.apiClientError(w, http.StatusNotFound)
+		default:
+			app.apiServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// withoutSlug returns the snippet as the API shows it to the user of the
+// request. Only the owner sees the slug, so that the slug of a public snippet
+// isn't published, in case the snippet becomes unlisted. The snippet itself
+// isn't modified.
+func (app *application) withoutSlug(r *http.Request, snippet *models.Snippet) *models.Snippet {
+	if snippet.UserID == app.authenticatedUserID(r) {
+		return snippet
+	}
+
+	shown := *snippet
+	shown.Slug = ""
+	return &shown
+}
+
+// apiOwnedSnippet is the JSON counterpart of ownedSnippet.
+func (app *application) apiOwnedSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.apiClientError(w, http.StatusNotFound)
+		return nil, false
+	}
+
+	snippet, err := app.findOwnedSnippet(r, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.apiClientError(w, http.StatusNotFound)
+		case errors.Is(err, errNotOwner):
+			app.apiClientError(w, http.StatusForbidden)
+		default:
+			app.apiServerError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// apiEditableSnippet is the JSON counterpart of editableSnippet.
+func (app *application) apiEditableSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, ok := app.apiOwnedSnippet(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if snippet.Expired() {
+		app.apiClientError(w, http.StatusNotFound)
+		return nil, false
+	}
+
+	return snippet, true
+}
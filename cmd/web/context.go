@@ -2,4 +2,18 @@ package main
 
 type contextKey string
 
-const isAuthenticatedContextKey = contextKey("isAuthenticated")
+const (
+	isAuthenticatedContextKey     = contextKey("isAuthenticated")
+	authenticatedUserIDContextKey = contextKey("authenticatedUserID")
+	tokenContextKey               = contextKey("token")
+	requestIDContextKey           = contextKey("requestID")
+	requestInfoContextKey         = contextKey("requestInfo")
+)
+
+// requestInfo is shared by logRequests with the rest of the middleware chain and
+// the router, so that they can report details which only they know about to the
+// access log.
+type requestInfo struct {
+	route  string
+	userID int
+}
@@ -3,18 +3,79 @@ package main
 import (
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/vladComan0/go-snippets/internal/highlight"
 	"github.com/vladComan0/go-snippets/internal/models"
 	"github.com/vladComan0/go-snippets/internal/validator"
 )
 
+// snippetFileForm holds one of the files of the snippet create and edit forms,
+// which are posted as files[0].name, files[0].language, files[0].content...
+type snippetFileForm struct {
+	Name     string `form:"name" json:"name"`
+	Language string `form:"language" json:"language"`
+	Content  string `form:"content" json:"content"`
+}
+
 type snippetCreateForm struct {
-	Title               string `form:"title"`
-	Content             string `form:"content"`
-	Expires             int    `form:"expires"`
+	Title               string            `form:"title" json:"title"`
+	Files               []snippetFileForm `form:"files" json:"files"`
+	Visibility          string            `form:"visibility" json:"visibility"`
+	Tags                tagList           `form:"tags" json:"tags"`
+	Expires             string            `form:"expires" json:"expires"`
+	ExpiresAt           string            `form:"expires_at" json:"expires_at"`
+	BurnAfterReading    bool              `form:"burn_after_reading" json:"burn_after_reading"`
+	ForkedFrom          string            `form:"forked_from" json:"-"`
+	validator.Validator `form:"-" json:"-"`
+
+	// expiry is the date the snippet expires at, set by validate.
+	expiry *time.Time
+}
+
+// validate checks the untrusted user input of the form. The same rules apply to
+// snippets created through the HTML form and through the JSON API.
+func (form *snippetCreateForm) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank.")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long.")
+	form.Files = normalizeFiles(form.Files)
+	checkFiles(&form.Validator, form.Files)
+	form.CheckField(validator.PermittedValue(form.Visibility, models.Visibilities...), "visibility", "This field must equal public, unlisted or private.")
+	checkTags(&form.Validator, form.Tags)
+	form.expiry = checkExpiry(&form.Validator, form.Expires, form.ExpiresAt, time.Now().UTC())
+}
+
+type snippetEditForm struct {
+	Title               string            `form:"title" json:"title"`
+	Files               []snippetFileForm `form:"files" json:"files"`
+	Visibility          string            `form:"visibility" json:"visibility"`
+	Tags                tagList           `form:"tags" json:"tags"`
+	validator.Validator `form:"-" json:"-"`
+}
+
+func (form *snippetEditForm) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank.")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long.")
+	form.Files = normalizeFiles(form.Files)
+	checkFiles(&form.Validator, form.Files)
+	form.CheckField(validator.PermittedValue(form.Visibility, models.Visibilities...), "visibility", "This field must equal public, unlisted or private.")
+	checkTags(&form.Validator, form.Tags)
+}
+
+type snippetRestoreForm struct {
+	Revision int `form:"revision"`
+}
+
+type snippetExtendForm struct {
+	Extend string `form:"extend"`
+}
+
+type snippetSearchForm struct {
+	Query               string `form:"q"`
 	validator.Validator `form:"-"`
 }
 
@@ -31,6 +92,13 @@ type userLoginForm struct {
 	validator.Validator `form:"-"`
 }
 
+type tokenCreateForm struct {
+	Name                string   `form:"name"`
+	Scopes              []string `form:"scopes"`
+	Expires             int      `form:"expires"`
+	validator.Validator `form:"-"`
+}
+
 type accountPasswordUpdateForm struct {
 	CurrentPassword         string `form:"currentPassword"`
 	NewPassword             string `form:"newPassword"`
@@ -39,44 +107,178 @@ type accountPasswordUpdateForm struct {
 }
 
 func (app *application) home(w http.ResponseWriter, r *http.Request) {
-	snippets, err := app.snippets.Latest()
+	var v validator.Validator
+	filters := app.readFilters(r.URL.Query(), 10, &v)
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, metadata, err := app.snippets.Latest(r.Context(), filters)
 	if err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 	data := app.newTemplateData(r)
 	data.Snippets = snippets
-	app.render(w, http.StatusOK, "home.tmpl.html", data)
+	data.Pagination = newPagination(metadata, r.URL.Query())
+	app.render(w, r, http.StatusOK, "home.tmpl.html", data)
 }
 
 func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
-	params := httprouter.ParamsFromContext(r.Context())
-	id, err := strconv.Atoi(params.ByName("id"))
-	if err != nil || id <= 0 {
-		app.clientError(w, http.StatusBadRequest)
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
 		return
 	}
-	snippet, err := app.snippets.Get(id)
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	buf, err := app.renderPage("view.tmpl.html", data)
 	if err != nil {
-		switch {
-		case errors.Is(err, models.ErrNoRecord):
+		app.serverError(w, r, err)
+		return
+	}
+
+	if err := app.burnRead(r, snippet); err != nil {
+		app.snippetLookupError(w, r, err)
+		return
+	}
+	app.metrics.snippetsViewed.WithLabelValues("web").Inc()
+
+	app.writePage(w, r, http.StatusOK, buf)
+}
+
+func (app *application) snippetRaw(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	file := requestedFile(r, snippet)
+	if file == nil {
+		app.clientError(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := w.Write([]byte(file.Content)); err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+// snippetDownload sends the requested file of the snippet as an attachment. If
+// no file is requested and the snippet has several files, they are all sent in
+// a zip archive.
+func (app *application) snippetDownload(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	if r.URL.Query().Has("file") || len(snippet.Files) == 1 {
+		file := requestedFile(r, snippet)
+		if file == nil {
 			app.clientError(w, http.StatusNotFound)
-		default:
-			app.serverError(w, err)
+			return
 		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": snippetFilename(snippet, file),
+		}))
+		if _, err := w.Write([]byte(file.Content)); err != nil {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Build the archive in memory first, so that a failure can still be
+	// reported with a proper error response.
+	archive, err := zipFiles(snippet.Files)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": snippetArchiveName(snippet),
+	}))
+	if _, err := w.Write(archive); err != nil {
+		app.serverError(w, r, err)
+	}
+}
+
+func (app *application) snippetSearch(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	var v validator.Validator
+	filters := app.readFilters(qs, 10, &v)
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
 		return
 	}
+
+	form := snippetSearchForm{
+		Query: strings.TrimSpace(qs.Get("q")),
+	}
+
 	data := app.newTemplateData(r)
-	data.Snippet = snippet
-	app.render(w, http.StatusOK, "view.tmpl.html", data)
+	data.Form = form
+
+	// An empty query only shows the search form.
+	if form.Query == "" {
+		app.render(w, r, http.StatusOK, "search.tmpl.html", data)
+		return
+	}
+
+	form.CheckField(validator.MaxChars(form.Query, 100), "q", "This field cannot be more than 100 characters long.")
+
+	if !form.Valid() {
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "search.tmpl.html", data)
+		return
+	}
+
+	snippets, metadata, err := app.snippets.Search(r.Context(), form.Query, filters)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data.Snippets = snippets
+	data.Pagination = newPagination(metadata, qs)
+	app.render(w, r, http.StatusOK, "search.tmpl.html", data)
 }
 
 func (app *application) snippetCreate(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Form = &snippetCreateForm{
-		Expires: 365,
+		Files:      []snippetFileForm{{Language: highlight.PlainText}},
+		Visibility: models.VisibilityPublic,
+		Expires:    "365d",
+	}
+	app.render(w, r, http.StatusOK, "create.tmpl.html", data)
+}
+
+// snippetFork shows the create form pre-filled with a copy of the snippet.
+func (app *application) snippetFork(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Form = &snippetCreateForm{
+		Title:      snippet.Title,
+		Files:      fileForms(snippet.Files),
+		Visibility: models.VisibilityPublic,
+		Tags:       tagList(strings.Join(snippet.Tags, ", ")),
+		Expires:    "365d",
+		ForkedFrom: snippet.Ref(),
 	}
-	app.render(w, http.StatusOK, "create.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "create.tmpl.html", data)
 }
 
 func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request) {
@@ -88,31 +290,314 @@ func (app *application) snippetCreatePost(w http.ResponseWriter, r *http.Request
 	}
 
 	// Validating untrusted user input
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank.")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long.")
-	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank.")
-	form.CheckField(validator.PermittedValue(form.Expires, 1, 7, 30, 365), "expires", "This field must equal 1, 7, 30 or 365.")
+	form.validate()
+
+	// A fork must still be visible to the user when it is saved, as the source
+	// snippet may have been deleted or made private in the meantime.
+	var forkedFrom int
+	if form.ForkedFrom != "" {
+		source, err := app.lookupSnippetRef(r, form.ForkedFrom)
+		switch {
+		case err == nil:
+			forkedFrom = source.ID
+		case errors.Is(err, errInvalidID), errors.Is(err, models.ErrNoRecord):
+			form.AddNonFieldError("The snippet you are forking is no longer available.")
+		default:
+			app.serverError(w, r, err)
+			return
+		}
+	}
 
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity, "create.tmpl.html", data)
+		app.render(w, r, http.StatusUnprocessableEntity, "create.tmpl.html", data)
 		return
 	}
 
-	id, err := app.snippets.Insert(form.Title, form.Content, form.Expires)
-	if err != nil {
-		app.serverError(w, err)
+	snippet := &models.Snippet{
+		UserID:     app.authenticatedUserID(r),
+		Title:      form.Title,
+		Files:      toFiles(form.Files),
+		Visibility: form.Visibility,
+		Tags:       parseTags(string(form.Tags)),
+		ForkedFrom: forkedFrom,
+		Expires:    form.expiry,
+
+		BurnAfterReading: form.BurnAfterReading,
 	}
+	if err := app.snippets.Insert(r.Context(), snippet); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	app.metrics.snippetsCreated.WithLabelValues("web").Inc()
 
 	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully created!")
-	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%s", snippet.Ref()), http.StatusSeeOther)
+}
+
+func (app *application) snippetEdit(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.editableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Form = snippetEditForm{
+		Title:      snippet.Title,
+		Files:      fileForms(snippet.Files),
+		Visibility: snippet.Visibility,
+		Tags:       tagList(strings.Join(snippet.Tags, ", ")),
+	}
+	app.render(w, r, http.StatusOK, "edit.tmpl.html", data)
+}
+
+func (app *application) snippetEditPost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.editableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	var form snippetEditForm
+
+	if err := app.decodePostForm(r, &form); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "edit.tmpl.html", data)
+		return
+	}
+
+	updated := *snippet
+	updated.Title = form.Title
+	updated.Files = toFiles(form.Files)
+	updated.Visibility = form.Visibility
+	updated.Tags = parseTags(string(form.Tags))
+	if err := app.snippets.Update(r.Context(), &updated, app.authenticatedUserID(r)); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully updated!")
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%s", updated.Ref()), http.StatusSeeOther)
+}
+
+func (app *application) snippetDeletePost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.ownedSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	if err := app.snippets.Delete(r.Context(), snippet.ID); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.clientError(w, http.StatusNotFound)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully deleted!")
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// snippetExtendPost pushes the expiry date of a snippet forward. Unlike the other
+// actions reserved to the owner, it also renews snippets which have expired but
+// haven't been purged yet, so that they can be rescued from the account page.
+func (app *application) snippetExtendPost(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	var form snippetExtendForm
+
+	if err := app.decodePostForm(r, &form); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	by, ok := extensionDuration(form.Extend)
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Ownership is checked by the query itself, as expired snippets can't be
+	// fetched anymore. Snippets of other users are reported as not found.
+	if err := app.snippets.Extend(r.Context(), id, app.authenticatedUserID(r), by); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.clientError(w, http.StatusNotFound)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	snippet, err := app.snippets.Get(r.Context(), id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet expiry successfully extended!")
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%s", snippet.Ref()), http.StatusSeeOther)
+}
+
+func (app *application) snippetTag(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	tag := strings.ToLower(params.ByName("name"))
+	if !validator.Matches(tag, tagRX) {
+		app.clientError(w, http.StatusNotFound)
+		return
+	}
+
+	var v validator.Validator
+	filters := app.readFilters(r.URL.Query(), 10, &v)
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, metadata, err := app.snippets.ByTag(r.Context(), tag, filters)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tag = tag
+	data.Snippets = snippets
+	data.Pagination = newPagination(metadata, r.URL.Query())
+	app.render(w, r, http.StatusOK, "tag.tmpl.html", data)
+}
+
+func (app *application) snippetHistory(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := app.snippets.Revisions(r.Context(), snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
+	app.render(w, r, http.StatusOK, "history.tmpl.html", data)
+}
+
+func (app *application) snippetDiff(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.viewableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := app.snippets.Revisions(r.Context(), snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	if len(revisions) == 0 {
+		app.clientError(w, http.StatusNotFound)
+		return
+	}
+
+	// By default, compare the current revision with the one before it.
+	qs := r.URL.Query()
+
+	var v validator.Validator
+	to := app.readInt(qs, "to", revisions[0].Number, &v)
+	from := app.readInt(qs, "from", max(to-1, 1), &v)
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	var fromRevision, toRevision *models.Revision
+	for _, revision := range revisions {
+		if revision.Number == from {
+			fromRevision = revision
+		}
+		if revision.Number == to {
+			toRevision = revision
+		}
+	}
+	if fromRevision == nil || toRevision == nil {
+		app.clientError(w, http.StatusNotFound)
+		return
+	}
+
+	diff, err := newRevisionDiff(fromRevision, toRevision)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
+	data.Diff = diff
+	app.render(w, r, http.StatusOK, "diff.tmpl.html", data)
+}
+
+func (app *application) snippetRestorePost(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.editableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	var form snippetRestoreForm
+
+	if err := app.decodePostForm(r, &form); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	revision, err := app.snippets.Revision(r.Context(), snippet.ID, form.Revision)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.clientError(w, http.StatusNotFound)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Restoring records a new revision rather than discarding the later ones, so
+	// that the history stays complete.
+	updated := *snippet
+	updated.Title = revision.Title
+	updated.Files = revision.Files
+	if err := app.snippets.Update(r.Context(), &updated, app.authenticatedUserID(r)); err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", fmt.Sprintf("Snippet restored to revision %d!", revision.Number))
+	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%s", updated.Ref()), http.StatusSeeOther)
 }
 
 func (app *application) userSignup(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Form = userSignupForm{}
-	app.render(w, http.StatusOK, "signup.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "signup.tmpl.html", data)
 }
 
 func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
@@ -133,19 +618,19 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity, "signup.tmpl.html", data)
+		app.render(w, r, http.StatusUnprocessableEntity, "signup.tmpl.html", data)
 		return
 	}
 
-	if err := app.users.Insert(form.Name, form.Email, form.Password); err != nil {
+	if err := app.users.Insert(r.Context(), form.Name, form.Email, form.Password); err != nil {
 		switch {
 		case errors.Is(err, models.ErrDuplicateEmail):
 			form.AddFieldError("email", "E-mail address already used.")
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.render(w, http.StatusUnprocessableEntity, "signup.tmpl.html", data)
+			app.render(w, r, http.StatusUnprocessableEntity, "signup.tmpl.html", data)
 		default:
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
@@ -158,7 +643,7 @@ func (app *application) userSignupPost(w http.ResponseWriter, r *http.Request) {
 func (app *application) userLogin(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Form = userLoginForm{}
-	app.render(w, http.StatusOK, "login.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "login.tmpl.html", data)
 }
 
 func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
@@ -175,13 +660,13 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity, "login.tmpl.html", data)
+		app.render(w, r, http.StatusUnprocessableEntity, "login.tmpl.html", data)
 		return
 	}
 
 	// Check whether the credentials are valid. If they're not, add a generic
 	// non-field error message and re-display the login page.
-	id, err := app.users.Authenticate(form.Email, form.Password)
+	id, err := app.users.Authenticate(r.Context(), form.Email, form.Password)
 	if err != nil {
 		switch {
 		case errors.Is(err, models.ErrInvalidCredentials):
@@ -189,9 +674,9 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.render(w, http.StatusUnprocessableEntity, "login.tmpl.html", data)
+			app.render(w, r, http.StatusUnprocessableEntity, "login.tmpl.html", data)
 		default:
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
@@ -201,7 +686,7 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 	// authentication state or privilege levels changes for the user (e.g. login
 	// and logout operations).
 	if err := app.sessionManager.RenewToken(r.Context()); err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -223,7 +708,7 @@ func (app *application) userLoginPost(w http.ResponseWriter, r *http.Request) {
 
 func (app *application) userLogoutPost(w http.ResponseWriter, r *http.Request) {
 	if err := app.sessionManager.RenewToken(r.Context()); err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 		return
 	}
 
@@ -237,38 +722,52 @@ func (app *application) userLogoutPost(w http.ResponseWriter, r *http.Request) {
 
 func (app *application) ping(w http.ResponseWriter, r *http.Request) {
 	if _, err := w.Write([]byte("OK")); err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 	}
 }
 
 func (app *application) about(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
-	app.render(w, http.StatusOK, "about.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "about.tmpl.html", data)
 }
 
 func (app *application) accountView(w http.ResponseWriter, r *http.Request) {
-	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+	var v validator.Validator
+	filters := app.readFilters(r.URL.Query(), 10, &v)
+	if !v.Valid() {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
 
-	user, err := app.users.Get(userID)
+	user, err := app.users.Get(r.Context(), app.authenticatedUserID(r))
 	if err != nil {
 		if errors.Is(err, models.ErrNoRecord) {
 			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 		} else {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
+		return
+	}
+
+	snippets, metadata, err := app.snippets.ByUser(r.Context(), user.ID, filters)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
 	}
 
 	data := app.newTemplateData(r)
 	data.User = user
+	data.Snippets = snippets
+	data.Pagination = newPagination(metadata, r.URL.Query())
 
-	app.render(w, http.StatusOK, "account.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "account.tmpl.html", data)
 }
 
 func (app *application) accountPasswordUpdate(w http.ResponseWriter, r *http.Request) {
 	data := app.newTemplateData(r)
 	data.Form = accountPasswordUpdateForm{}
 
-	app.render(w, http.StatusOK, "password.tmpl.html", data)
+	app.render(w, r, http.StatusOK, "password.tmpl.html", data)
 }
 
 func (app *application) accountPasswordUpdatePost(w http.ResponseWriter, r *http.Request) {
@@ -292,25 +791,24 @@ func (app *application) accountPasswordUpdatePost(w http.ResponseWriter, r *http
 	if !form.Valid() {
 		data := app.newTemplateData(r)
 		data.Form = form
-		app.render(w, http.StatusUnprocessableEntity, "password.tmpl.html", data)
+		app.render(w, r, http.StatusUnprocessableEntity, "password.tmpl.html", data)
 		return
 	}
 
-	userID := app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
-	if err := app.users.UpdatePassword(userID, form.CurrentPassword, form.NewPassword); err != nil {
+	if err := app.users.UpdatePassword(r.Context(), app.authenticatedUserID(r), form.CurrentPassword, form.NewPassword); err != nil {
 		switch {
 		case errors.Is(err, models.ErrInvalidCredentials):
 			form.AddFieldError("currentPassword", "Current password is incorrect.")
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.render(w, http.StatusUnprocessableEntity, "password.tmpl.html", data)
+			app.render(w, r, http.StatusUnprocessableEntity, "password.tmpl.html", data)
 		case errors.Is(err, models.ErrSamePassword):
 			form.AddFieldError("newPassword", "New password cannot be the same as the current password.")
 			data := app.newTemplateData(r)
 			data.Form = form
-			app.render(w, http.StatusUnprocessableEntity, "password.tmpl.html", data)
+			app.render(w, r, http.StatusUnprocessableEntity, "password.tmpl.html", data)
 		default:
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 		}
 		return
 	}
@@ -319,3 +817,66 @@ func (app *application) accountPasswordUpdatePost(w http.ResponseWriter, r *http
 	app.sessionManager.Put(r.Context(), "flash", "Your password has been updated successfully!")
 	http.Redirect(w, r, "/account/view", http.StatusSeeOther)
 }
+
+func (app *application) accountTokens(w http.ResponseWriter, r *http.Request) {
+	app.renderTokens(w, r, http.StatusOK, tokenCreateForm{
+		Scopes:  []string{models.ScopeSnippetsRead, models.ScopeSnippetsWrite},
+		Expires: 30,
+	})
+}
+
+func (app *application) accountTokensPost(w http.ResponseWriter, r *http.Request) {
+	var form tokenCreateForm
+
+	if err := app.decodePostForm(r, &form); err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank.")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long.")
+	form.CheckField(len(form.Scopes) > 0, "scopes", "Select at least one scope.")
+	for _, scope := range form.Scopes {
+		form.CheckField(validator.PermittedValue(scope, models.Scopes...), "scopes", "This field contains an unknown scope.")
+	}
+	form.CheckField(validator.PermittedValue(form.Expires, 30, 90, 365), "expires", "This field must equal 30, 90 or 365.")
+
+	if !form.Valid() {
+		app.renderTokens(w, r, http.StatusUnprocessableEntity, form)
+		return
+	}
+
+	ttl := time.Duration(form.Expires) * 24 * time.Hour
+	token, err := app.tokens.New(r.Context(), app.authenticatedUserID(r), form.Name, form.Scopes, ttl)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// The plaintext token can't be recovered from its hash, so it is shown to
+	// the user exactly once, on the page they are redirected to.
+	app.sessionManager.Put(r.Context(), "newToken", token.Plaintext)
+	app.sessionManager.Put(r.Context(), "flash", "Token successfully created! Make sure to copy it now, you won't be able to see it again.")
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
+
+func (app *application) accountTokenRevokePost(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	if err := app.tokens.Delete(r.Context(), id, app.authenticatedUserID(r)); err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.clientError(w, http.StatusNotFound)
+		default:
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Token successfully revoked!")
+	http.Redirect(w, r, "/account/tokens", http.StatusSeeOther)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"archive/zip"
+	"context"
+	"html/template"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vladComan0/go-snippets/internal/assert"
+	"github.com/vladComan0/go-snippets/internal/models/mocks"
 )
 
 func TestPing(t *testing.T) {
@@ -28,6 +34,59 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, responseBody, "OK")
 }
 
+func TestHome(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Default page",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Explicit page and limit",
+			urlPath:  "/?page=1&limit=5",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Negative page",
+			urlPath:  "/?page=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Decimal page",
+			urlPath:  "/?page=1.5",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Zero limit",
+			urlPath:  "/?limit=0",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+}
+
 func TestSnippetView(t *testing.T) {
 	app := newTestApplication(t)
 
@@ -71,6 +130,311 @@ func TestSnippetView(t *testing.T) {
 			urlPath:  "/snippet/view/",
 			wantCode: http.StatusNotFound,
 		},
+		{
+			name:     "Markdown",
+			urlPath:  "/snippet/view/" + mocks.MockUnlistedSlug,
+			wantCode: http.StatusOK,
+			wantBody: `<em><a href="https://example.com/basho" rel="nofollow noopener">Basho</a></em>`,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetVisibility(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		urlPath       string
+		wantAnonymous int
+		wantOwner     int
+	}{
+		{
+			name:          "Public snippet by ID",
+			urlPath:       "/snippet/view/3",
+			wantAnonymous: http.StatusOK,
+			wantOwner:     http.StatusOK,
+		},
+		{
+			name:          "Public snippet by slug",
+			urlPath:       "/snippet/view/Jr8hN1pYe5UoQa3s",
+			wantAnonymous: http.StatusOK,
+			wantOwner:     http.StatusOK,
+		},
+		{
+			name:          "Unlisted snippet by slug",
+			urlPath:       "/snippet/view/" + mocks.MockUnlistedSlug,
+			wantAnonymous: http.StatusOK,
+			wantOwner:     http.StatusOK,
+		},
+		{
+			name:          "Unlisted snippet by ID",
+			urlPath:       "/snippet/view/4",
+			wantAnonymous: http.StatusNotFound,
+			wantOwner:     http.StatusNotFound,
+		},
+		{
+			name:          "Private snippet of the owner",
+			urlPath:       "/snippet/view/5",
+			wantAnonymous: http.StatusNotFound,
+			wantOwner:     http.StatusOK,
+		},
+		{
+			name:          "Raw private snippet of the owner",
+			urlPath:       "/snippet/raw/5",
+			wantAnonymous: http.StatusNotFound,
+			wantOwner:     http.StatusOK,
+		},
+		{
+			name:          "Unknown slug",
+			urlPath:       "/snippet/view/AAAAAAAAAAAAAAAA",
+			wantAnonymous: http.StatusNotFound,
+			wantOwner:     http.StatusNotFound,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name+" (anonymous)", func(t *testing.T) {
+			code, _, _ := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantAnonymous)
+		})
+	}
+
+	// Alice owns the private snippet, but the unlisted one belongs to Bob.
+	ts.login(t)
+
+	for _, subtest := range tests {
+		t.Run(subtest.name+" (owner)", func(t *testing.T) {
+			code, _, _ := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantOwner)
+		})
+	}
+}
+
+func TestSnippetBurnAfterReading(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("First reader", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/"+mocks.MockBurnSlug)
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "A lightning flash, between the forest trees...")
+		assert.StringContains(t, body, "This snippet was burnt after reading")
+		assert.Equal(t, strings.Contains(body, "/snippet/download/"), false)
+	})
+
+	t.Run("Concurrent reader", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/snippet/view/"+mocks.MockBurntSlug)
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	// Only the view page reads, and burns, the snippet: the other pages refuse
+	// burn-after-reading snippets rather than consuming them.
+	for _, urlPath := range []string{
+		"/snippet/raw/" + mocks.MockBurnSlug,
+		"/snippet/download/" + mocks.MockBurnSlug,
+		"/snippet/view/" + mocks.MockBurnSlug + "/history",
+		"/snippet/view/" + mocks.MockBurnSlug + "/diff",
+	} {
+		t.Run("Not read by "+urlPath, func(t *testing.T) {
+			code, _, _ := ts.get(t, urlPath)
+
+			assert.Equal(t, code, http.StatusNotFound)
+		})
+	}
+
+	// Alice owns both snippets, and her views don't burn them.
+	ts.login(t)
+
+	t.Run("Owner", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/"+mocks.MockBurntSlug)
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, strings.Contains(body, "This snippet was burnt after reading"), false)
+		assert.StringContains(t, body, "/snippet/download/"+mocks.MockBurntSlug)
+	})
+}
+
+// burnRecorder records the snippets burnt after being read.
+type burnRecorder struct {
+	mocks.SnippetModel
+	burnt []int
+}
+
+func (m *burnRecorder) Burn(ctx context.Context, id int) error {
+	m.burnt = append(m.burnt, id)
+	return m.SnippetModel.Burn(ctx, id)
+}
+
+func TestSnippetBurnAfterFailedRendering(t *testing.T) {
+	app := newTestApplication(t)
+	snippets := &burnRecorder{}
+	app.snippets = snippets
+	app.templateCache["view.tmpl.html"] = template.Must(template.New("base").Parse("{{.NoSuchField}}"))
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, _ := ts.get(t, "/snippet/view/"+mocks.MockBurnSlug)
+
+	assert.Equal(t, code, http.StatusInternalServerError)
+	assert.Equal(t, len(snippets.burnt), 0)
+
+	code, _, _ = ts.get(t, "/api/v1/snippets/"+mocks.MockBurnSlug)
+
+	assert.Equal(t, code, http.StatusOK)
+	assert.Equal(t, len(snippets.burnt), 1)
+}
+
+func TestSnippetRaw(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name            string
+		urlPath         string
+		wantCode        int
+		wantBody        string
+		wantDisposition string
+	}{
+		{
+			name:     "Raw first file",
+			urlPath:  "/snippet/raw/1",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond...",
+		},
+		{
+			name:     "Raw named file",
+			urlPath:  "/snippet/raw/1?file=frog.txt",
+			wantCode: http.StatusOK,
+			wantBody: "A frog jumps into the pond, splash! Silence again.",
+		},
+		{
+			name:     "Raw non-existent file",
+			urlPath:  "/snippet/raw/1?file=toad.txt",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:            "Download named file",
+			urlPath:         "/snippet/download/1?file=pond.txt",
+			wantCode:        http.StatusOK,
+			wantBody:        "An old silent pond...",
+			wantDisposition: `attachment; filename=pond.txt`,
+		},
+		{
+			name:            "Download single-file snippet",
+			urlPath:         "/snippet/download/3",
+			wantCode:        http.StatusOK,
+			wantBody:        "Over the wintry forest, winds howl in rage...",
+			wantDisposition: `attachment; filename=over-the-wintry-forest.txt`,
+		},
+		{
+			name:     "Raw non-existent ID",
+			urlPath:  "/snippet/raw/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Download invalid ID",
+			urlPath:  "/snippet/download/foo",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.Equal(t, body, subtest.wantBody)
+				assert.Equal(t, headers.Get("Content-Type"), "text/plain; charset=utf-8")
+			}
+			assert.Equal(t, headers.Get("Content-Disposition"), subtest.wantDisposition)
+		})
+	}
+
+	t.Run("Download multi-file snippet", func(t *testing.T) {
+		code, headers, body := ts.get(t, "/snippet/download/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Content-Type"), "application/zip")
+		assert.Equal(t, headers.Get("Content-Disposition"), `attachment; filename=an-old-silent-pond.zip`)
+
+		archive, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+		assert.NilError(t, err)
+		assert.Equal(t, len(archive.File), 2)
+		assert.Equal(t, archive.File[0].Name, "pond.txt")
+		assert.Equal(t, archive.File[1].Name, "frog.txt")
+	})
+}
+
+func TestSnippetSearch(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Empty query",
+			urlPath:  "/snippet/search",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/snippet/search' method='GET' class='search' novalidate>",
+		},
+		{
+			name:     "Matching query",
+			urlPath:  "/snippet/search?q=pond",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent <mark>pond</mark>",
+		},
+		{
+			name:     "Matching second file",
+			urlPath:  "/snippet/search?q=frog",
+			wantCode: http.StatusOK,
+			wantBody: "<mark>frog</mark>",
+		},
+		{
+			name:     "No results",
+			urlPath:  "/snippet/search?q=toad",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets match your search.",
+		},
+		{
+			name:     "Query too long",
+			urlPath:  "/snippet/search?q=" + strings.Repeat("a", 101),
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/snippet/search?q=pond&page=foo",
+			wantCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, subtest := range tests {
@@ -233,4 +597,869 @@ func TestSnippetCreate(t *testing.T) {
 		assert.Equal(t, code, http.StatusOK)
 		assert.StringContains(t, body, "<form action='/snippet/create' method='POST'>")
 	})
+
+	csrfToken := ts.login(t)
+
+	tests := []struct {
+		name         string
+		title        string
+		content      string
+		language     string
+		visibility   string
+		tags         string
+		expires      string
+		expiresAt    string
+		burn         string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Valid submission",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			language:     "go",
+			visibility:   "public",
+			tags:         "Haiku, nature, haiku",
+			expires:      "7d",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/2",
+		},
+		{
+			name:         "Unlisted snippet",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			language:     "go",
+			visibility:   "unlisted",
+			expires:      "7d",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Nw1bV3cX5zL7kJ9h",
+		},
+		{
+			name:       "Too many tags",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "go",
+			visibility: "public",
+			tags:       "a, b, c, d, e, f",
+			expires:    "7d",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid tag",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "go",
+			visibility: "public",
+			tags:       "go, <script>",
+			expires:    "7d",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Unknown visibility",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "go",
+			visibility: "secret",
+			expires:    "7d",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Blank content",
+			title:      "O snail",
+			content:    " ",
+			language:   "go",
+			visibility: "public",
+			expires:    "7d",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Unknown language",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "haiku",
+			visibility: "public",
+			expires:    "7d",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Invalid expiry",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "go",
+			visibility: "public",
+			expires:    "3",
+			wantCode:   http.StatusUnprocessableEntity,
+		}, {
+			name:         "Never expires",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			language:     "go",
+			visibility:   "public",
+			expires:      "never",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/2",
+		},
+		{
+			name:         "Custom expiry",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			language:     "go",
+			visibility:   "public",
+			expires:      "custom",
+			expiresAt:    time.Now().UTC().Add(48 * time.Hour).Format("2006-01-02T15:04"),
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/2",
+		},
+		{
+			name:       "Past custom expiry",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "go",
+			visibility: "public",
+			expires:    "custom",
+			expiresAt:  "2020-01-01T10:00",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Missing custom expiry",
+			title:      "O snail",
+			content:    "Climb Mount Fuji",
+			language:   "go",
+			visibility: "public",
+			expires:    "custom",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Burn after reading",
+			title:        "O snail",
+			content:      "Climb Mount Fuji",
+			language:     "go",
+			visibility:   "unlisted",
+			expires:      "1h",
+			burn:         "true",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Nw1bV3cX5zL7kJ9h",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", subtest.title)
+			form.Add("files[0].content", subtest.content)
+			form.Add("files[0].language", subtest.language)
+			form.Add("visibility", subtest.visibility)
+			form.Add("tags", subtest.tags)
+			form.Add("expires", subtest.expires)
+			form.Add("expires_at", subtest.expiresAt)
+			form.Add("burn_after_reading", subtest.burn)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, "/snippet/create", form)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantLocation != "" {
+				assert.Equal(t, headers.Get("Location"), subtest.wantLocation)
+			}
+		})
+	}
+
+	t.Run("Several files", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("title", "Hello")
+		form.Add("files[0].name", "main.go")
+		form.Add("files[0].language", "go")
+		form.Add("files[0].content", "package main")
+		form.Add("files[1].name", "go.mod")
+		form.Add("files[1].language", "plaintext")
+		form.Add("files[1].content", "module hello")
+		form.Add("visibility", "public")
+		form.Add("expires", "7d")
+		form.Add("csrf_token", csrfToken)
+
+		code, _, _ := ts.postForm(t, "/snippet/create", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		form.Set("files[1].name", "main.go")
+
+		code, _, body := ts.postForm(t, "/snippet/create", form)
+		assert.Equal(t, code, http.StatusUnprocessableEntity)
+		assert.StringContains(t, body, "Every file of a snippet must have a different name.")
+	})
+}
+func TestSnippetFork(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Fork counts", func(t *testing.T) {
+		_, _, body := ts.get(t, "/snippet/view/1")
+		assert.StringContains(t, body, "1 fork")
+
+		_, _, body = ts.get(t, "/snippet/view/3")
+		assert.StringContains(t, body, "forked from <a href='/snippet/view/1'>#1</a>")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/snippet/fork/3")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	csrfToken := ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Another user's snippet",
+			urlPath:  "/snippet/fork/3",
+			wantCode: http.StatusOK,
+			wantBody: "<input type='hidden' name='forked_from' value='3'>",
+		},
+		{
+			name:     "Unlisted snippet by slug",
+			urlPath:  "/snippet/fork/" + mocks.MockUnlistedSlug,
+			wantCode: http.StatusOK,
+			wantBody: "First autumn morning, the mirror I stare into...",
+		},
+		{
+			name:     "Unlisted snippet by ID",
+			urlPath:  "/snippet/fork/4",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+
+	postTests := []struct {
+		name       string
+		forkedFrom string
+		wantCode   int
+	}{
+		{
+			name:       "Valid fork",
+			forkedFrom: "3",
+			wantCode:   http.StatusSeeOther,
+		},
+		{
+			name:       "Fork of a hidden snippet",
+			forkedFrom: "4",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Fork of a non-existent snippet",
+			forkedFrom: "2",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, subtest := range postTests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", "Over the wintry forest")
+			form.Add("files[0].name", "forest.txt")
+			form.Add("files[0].content", "Over the wintry forest, winds howl in rage...")
+			form.Add("files[0].language", "plaintext")
+			form.Add("visibility", "public")
+			form.Add("expires", "7d")
+			form.Add("forked_from", subtest.forkedFrom)
+			form.Add("csrf_token", csrfToken)
+
+			code, _, _ := ts.postForm(t, "/snippet/create", form)
+
+			assert.Equal(t, code, subtest.wantCode)
+		})
+	}
+}
+
+func TestSnippetEdit(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/snippet/edit/1")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	csrfToken := ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Owner",
+			urlPath:  "/snippet/edit/1",
+			wantCode: http.StatusOK,
+			wantBody: "<form action='/snippet/edit/1' method='POST'>",
+		},
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/edit/3",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Someone else's unlisted snippet",
+			urlPath:  "/snippet/edit/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/edit/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Expired",
+			urlPath:  "/snippet/edit/8",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "String ID",
+			urlPath:  "/snippet/edit/foo",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+
+	postTests := []struct {
+		name       string
+		urlPath    string
+		title      string
+		content    string
+		language   string
+		visibility string
+		wantCode   int
+	}{
+		{
+			name:       "Valid submission",
+			urlPath:    "/snippet/edit/1",
+			title:      "A new title",
+			content:    "Some new content",
+			language:   "plaintext",
+			visibility: "public",
+			wantCode:   http.StatusSeeOther,
+		},
+		{
+			name:       "Empty title",
+			urlPath:    "/snippet/edit/1",
+			content:    "Some new content",
+			language:   "plaintext",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Missing language",
+			urlPath:    "/snippet/edit/1",
+			title:      "A new title",
+			content:    "Some new content",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Missing visibility",
+			urlPath:  "/snippet/edit/1",
+			title:    "A new title",
+			content:  "Some new content",
+			language: "plaintext",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "Not the owner",
+			urlPath:    "/snippet/edit/3",
+			title:      "A new title",
+			content:    "Some new content",
+			language:   "plaintext",
+			visibility: "public",
+			wantCode:   http.StatusForbidden,
+		},
+	}
+
+	for _, subtest := range postTests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", subtest.title)
+			form.Add("files[0].content", subtest.content)
+			form.Add("files[0].language", subtest.language)
+			form.Add("visibility", subtest.visibility)
+			form.Add("csrf_token", csrfToken)
+
+			code, _, _ := ts.postForm(t, subtest.urlPath, form)
+
+			assert.Equal(t, code, subtest.wantCode)
+		})
+	}
+}
+
+func TestSnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	csrfToken := ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+	}{
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/delete/3",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Someone else's unlisted snippet",
+			urlPath:  "/snippet/delete/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/delete/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Owner",
+			urlPath:  "/snippet/delete/1",
+			wantCode: http.StatusSeeOther,
+		},
+		{
+			name:     "Expired",
+			urlPath:  "/snippet/delete/8",
+			wantCode: http.StatusSeeOther,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("csrf_token", csrfToken)
+
+			code, _, _ := ts.postForm(t, subtest.urlPath, form)
+
+			assert.Equal(t, code, subtest.wantCode)
+		})
+	}
+}
+
+func TestSnippetExtend(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	csrfToken := ts.login(t)
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		extend       string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Owner",
+			urlPath:      "/snippet/extend/1",
+			extend:       "30d",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/extend/3",
+			extend:   "30d",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Never expires",
+			urlPath:  "/snippet/extend/5",
+			extend:   "30d",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/extend/2",
+			extend:   "30d",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid ID",
+			urlPath:  "/snippet/extend/foo",
+			extend:   "30d",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Not a duration",
+			urlPath:  "/snippet/extend/1",
+			extend:   "never",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("extend", subtest.extend)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, subtest.urlPath, form)
+
+			assert.Equal(t, code, subtest.wantCode)
+			assert.Equal(t, headers.Get("Location"), subtest.wantLocation)
+		})
+	}
+
+	t.Run("Countdown", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "(in 364 days)")
+		assert.StringContains(t, body, "<form action='/snippet/extend/1' method='POST'>")
+	})
+}
+
+func TestSnippetTag(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		urlPath     string
+		wantCode    int
+		wantBody    []string
+		notWantBody string
+	}{
+		{
+			name:        "Tag with public snippets",
+			urlPath:     "/tag/haiku",
+			wantCode:    http.StatusOK,
+			wantBody:    []string{"An old silent pond", "Over the wintry forest"},
+			notWantBody: "First autumn morning",
+		},
+		{
+			name:     "Uppercase tag",
+			urlPath:  "/tag/NATURE",
+			wantCode: http.StatusOK,
+			wantBody: []string{"Snippets tagged <span class='tag'>nature</span>", "An old silent pond"},
+		},
+		{
+			name:     "Unused tag",
+			urlPath:  "/tag/rust",
+			wantCode: http.StatusOK,
+			wantBody: []string{"There are no snippets with this tag yet."},
+		},
+		{
+			name:     "Invalid tag",
+			urlPath:  "/tag/%24money",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/tag/haiku?page=0",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			for _, want := range subtest.wantBody {
+				assert.StringContains(t, body, want)
+			}
+			if subtest.notWantBody != "" {
+				assert.Equal(t, strings.Contains(body, subtest.notWantBody), false)
+			}
+		})
+	}
+}
+
+func TestSnippetHistory(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "History",
+			urlPath:  "/snippet/view/1/history",
+			wantCode: http.StatusOK,
+			wantBody: "#2 (current)",
+		},
+		{
+			name:     "History of a private snippet",
+			urlPath:  "/snippet/view/5/history",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Default diff",
+			urlPath:  "/snippet/view/1/diff",
+			wantCode: http.StatusOK,
+			wantBody: "Revision 1 &rarr; revision 2",
+		},
+		{
+			name:     "Diff between explicit revisions",
+			urlPath:  "/snippet/view/1/diff?from=2&to=1",
+			wantCode: http.StatusOK,
+			wantBody: "Revision 2 &rarr; revision 1",
+		},
+		{
+			name:     "Identical revisions",
+			urlPath:  "/snippet/view/1/diff?from=2&to=2",
+			wantCode: http.StatusOK,
+			wantBody: "The content of both revisions is identical.",
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/snippet/view/1/diff?from=1&to=7",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid revision",
+			urlPath:  "/snippet/view/1/diff?from=foo",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+
+	csrfToken := ts.login(t)
+
+	restoreTests := []struct {
+		name     string
+		urlPath  string
+		revision string
+		wantCode int
+	}{
+		{
+			name:     "Not the owner",
+			urlPath:  "/snippet/restore/3",
+			revision: "1",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/snippet/restore/1",
+			revision: "7",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Owner",
+			urlPath:  "/snippet/restore/1",
+			revision: "1",
+			wantCode: http.StatusSeeOther,
+		},
+	}
+
+	for _, subtest := range restoreTests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("revision", subtest.revision)
+			form.Add("csrf_token", csrfToken)
+
+			code, _, _ := ts.postForm(t, subtest.urlPath, form)
+
+			assert.Equal(t, code, subtest.wantCode)
+		})
+	}
+}
+
+func TestAccountView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/account/view")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	ts.login(t)
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Default page",
+			urlPath:  "/account/view",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Second page",
+			urlPath:  "/account/view?page=2",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Zero page",
+			urlPath:  "/account/view?page=0",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "String page",
+			urlPath:  "/account/view?page=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Limit too large",
+			urlPath:  "/account/view?limit=1000",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			code, _, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			if subtest.wantBody != "" {
+				assert.StringContains(t, body, subtest.wantBody)
+			}
+		})
+	}
+}
+
+func TestAccountTokens(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	csrfToken := ts.login(t)
+
+	code, _, body := ts.get(t, "/account/tokens")
+	assert.Equal(t, code, http.StatusOK)
+	assert.StringContains(t, body, "Dashboard")
+
+	tests := []struct {
+		name      string
+		tokenName string
+		scopes    []string
+		expires   string
+		wantCode  int
+	}{
+		{
+			name:     "Empty name",
+			scopes:   []string{"snippets:write"},
+			expires:  "90",
+			wantCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "No scopes",
+			tokenName: "CLI",
+			expires:   "90",
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Unknown scope",
+			tokenName: "CLI",
+			scopes:    []string{"users:write"},
+			expires:   "90",
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Invalid expiry",
+			tokenName: "CLI",
+			scopes:    []string{"snippets:write"},
+			expires:   "1",
+			wantCode:  http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Valid submission",
+			tokenName: "CLI",
+			scopes:    []string{"snippets:write", "account:read"},
+			expires:   "90",
+			wantCode:  http.StatusSeeOther,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("name", subtest.tokenName)
+			for _, scope := range subtest.scopes {
+				form.Add("scopes", scope)
+			}
+			form.Add("expires", subtest.expires)
+			form.Add("csrf_token", csrfToken)
+
+			code, _, _ := ts.postForm(t, "/account/tokens", form)
+
+			assert.Equal(t, code, subtest.wantCode)
+		})
+	}
+
+	t.Run("New token is shown once", func(t *testing.T) {
+		_, _, body := ts.get(t, "/account/tokens")
+		assert.StringContains(t, body, mocks.MockToken)
+
+		_, _, body = ts.get(t, "/account/tokens")
+		assert.Equal(t, strings.Contains(body, mocks.MockToken), false)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("csrf_token", csrfToken)
+
+		code, _, _ := ts.postForm(t, "/account/tokens/revoke/1", form)
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		code, _, _ = ts.postForm(t, "/account/tokens/revoke/5", form)
+		assert.Equal(t, code, http.StatusNotFound)
+	})
 }
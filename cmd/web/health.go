@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds how long every component is given to respond to a
+// health check, so that the checks fail rather than hang while the database is
+// unreachable.
+const healthCheckTimeout = 2 * time.Second
+
+// pinger is implemented by *sql.DB.
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// componentStatus is the result of checking a single component.
+type componentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+// healthCheck checks a component of the application, returning an error if it
+// can't be used.
+type healthCheck func(ctx context.Context) error
+
+// healthz is the liveness check: it only fails if the process itself is broken,
+// in which case restarting it helps. A database outage doesn't make it fail, see
+// readyz.
+func (app *application) healthz(w http.ResponseWriter, r *http.Request) {
+	app.healthResponse(w, r, map[string]healthCheck{
+		"templates": app.checkTemplates,
+	})
+}
+
+// readyz is the readiness check: it fails while any of the components needed to
+// serve requests is unavailable, so that no traffic is routed to the instance.
+func (app *application) readyz(w http.ResponseWriter, r *http.Request) {
+	app.healthResponse(w, r, map[string]healthCheck{
+		"database":  app.checkDatabase,
+		"sessions":  app.checkSessions,
+		"templates": app.checkTemplates,
+	})
+}
+
+// healthResponse runs the checks and reports their results, with a 503 status
+// if any of them failed. The errors are logged rather than sent, since the
+// endpoints are public.
+func (app *application) healthResponse(w http.ResponseWriter, r *http.Request, checks map[string]healthCheck) {
+	status := http.StatusOK
+	components := make(map[string]componentStatus, len(checks))
+
+	for name, check := range checks {
+		start := time.Now()
+		err := runHealthCheck(r.Context(), check)
+		component := componentStatus{
+			Status:    "ok",
+			LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if err != nil {
+			app.logger.Error("health check failed", "request_id", app.requestID(r), "component", name, "error", err)
+			component.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+		components[name] = component
+	}
+
+	overall := "ok"
+	if status != http.StatusOK {
+		overall = "unavailable"
+	}
+
+	headers := make(http.Header)
+	headers.Set("Cache-Control", "no-store")
+
+	if err := app.writeJSON(w, status, envelope{"status": overall, "components": components}, headers); err != nil {
+		app.apiServerError(w, r, err)
+	}
+}
+
+// runHealthCheck runs check with a healthCheckTimeout deadline. The check runs in
+// its own goroutine, so that checks which don't honour the context, like the
+// session store lookups, can't hang the response either.
+func runHealthCheck(ctx context.Context, check healthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- check(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (app *application) checkDatabase(ctx context.Context) error {
+	return app.db.PingContext(ctx)
+}
+
+// checkSessions looks up a session which doesn't exist, which only fails if the
+// session store can't be reached.
+func (app *application) checkSessions(ctx context.Context) error {
+	_, _, err := app.sessionManager.Store.Find("healthcheck")
+	return err
+}
+
+func (app *application) checkTemplates(ctx context.Context) error {
+	if len(app.templateCache) == 0 {
+		return errors.New("the template cache is empty")
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestHealthChecks(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlPath        string
+		dbErr          error
+		emptyTemplates bool
+		wantCode       int
+		wantStatus     string
+		wantComponents map[string]string
+	}{
+		{
+			name:           "Live",
+			urlPath:        "/healthz",
+			wantCode:       http.StatusOK,
+			wantStatus:     "ok",
+			wantComponents: map[string]string{"templates": "ok"},
+		},
+		{
+			name:           "Live without database",
+			urlPath:        "/healthz",
+			dbErr:          errors.New("connection refused"),
+			wantCode:       http.StatusOK,
+			wantStatus:     "ok",
+			wantComponents: map[string]string{"templates": "ok"},
+		},
+		{
+			name:           "Not live without templates",
+			urlPath:        "/healthz",
+			emptyTemplates: true,
+			wantCode:       http.StatusServiceUnavailable,
+			wantStatus:     "unavailable",
+			wantComponents: map[string]string{"templates": "unavailable"},
+		},
+		{
+			name:           "Ready",
+			urlPath:        "/readyz",
+			wantCode:       http.StatusOK,
+			wantStatus:     "ok",
+			wantComponents: map[string]string{"database": "ok", "sessions": "ok", "templates": "ok"},
+		},
+		{
+			name:           "Not ready without database",
+			urlPath:        "/readyz",
+			dbErr:          errors.New("connection refused"),
+			wantCode:       http.StatusServiceUnavailable,
+			wantStatus:     "unavailable",
+			wantComponents: map[string]string{"database": "unavailable", "sessions": "ok", "templates": "ok"},
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.db = &mockDB{err: subtest.dbErr}
+			if subtest.emptyTemplates {
+				app.templateCache = map[string]*template.Template{}
+			}
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			code, headers, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			assert.Equal(t, headers.Get("Cache-Control"), "no-store")
+
+			var response struct {
+				Status     string `json:"status"`
+				Components map[string]struct {
+					Status string `json:"status"`
+				} `json:"components"`
+			}
+			err := json.Unmarshal([]byte(body), &response)
+			assert.NilError(t, err)
+
+			assert.Equal(t, response.Status, subtest.wantStatus)
+			assert.Equal(t, len(response.Components), len(subtest.wantComponents))
+			for name, want := range subtest.wantComponents {
+				assert.Equal(t, response.Components[name].Status, want)
+			}
+			// The errors are logged, not leaked to the client.
+			assert.Equal(t, strings.Contains(body, "connection refused"), false)
+		})
+	}
+}
+
+func TestAdminRoutes(t *testing.T) {
+	app := newTestApplication(t)
+	ts := httptest.NewServer(app.adminRoutes())
+	defer ts.Close()
+
+	for _, urlPath := range []string{"/metrics", "/healthz", "/readyz"} {
+		t.Run(urlPath, func(t *testing.T) {
+			response, err := ts.Client().Get(ts.URL + urlPath)
+			assert.NilError(t, err)
+			response.Body.Close()
+
+			assert.Equal(t, response.StatusCode, http.StatusOK)
+		})
+	}
+}
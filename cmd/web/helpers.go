@@ -1,26 +1,198 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
 	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/form/v4"
+	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/nosurf"
+	"github.com/vladComan0/go-snippets/internal/highlight"
+	"github.com/vladComan0/go-snippets/internal/models"
+	"github.com/vladComan0/go-snippets/internal/validator"
 )
 
-func (app *application) serverError(w http.ResponseWriter, err error) {
-	trace := fmt.Sprintf("%s\n%s", err.Error(), debug.Stack())
+var (
+	errInvalidID = errors.New("invalid id parameter")
+	errNotOwner  = errors.New("snippet owned by another user")
+)
+
+// slugRX matches the random slugs snippets can be referenced by.
+var slugRX = regexp.MustCompile(fmt.Sprintf("^[A-Za-z0-9_-]{%d}$", models.SLUG_LENGTH))
+
+// tagRX matches a normalized tag name.
+var tagRX = regexp.MustCompile(`^[a-z0-9][a-z0-9.+_-]*$`)
+
+// maxTags is the maximum number of tags a snippet can have.
+const maxTags = 5
+
+// parseTags splits a comma-separated list of tags, lowercasing them and dropping
+// blanks and duplicates.
+func parseTags(value string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// tagList is the comma-separated list of tags of the snippet forms. API clients
+// send it as a JSON array of tags instead.
+type tagList string
+
+func (t *tagList) UnmarshalJSON(data []byte) error {
+	var tags []string
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if strings.Contains(tag, ",") {
+			return fmt.Errorf("tag %q contains a comma", tag)
+		}
+	}
+
+	*t = tagList(strings.Join(tags, ","))
+	return nil
+}
+
+// checkTags validates a comma-separated list of tags sent in the "tags" field.
+func checkTags(v *validator.Validator, value tagList) {
+	tags := parseTags(string(value))
+	v.CheckField(len(tags) <= maxTags, "tags", fmt.Sprintf("This field cannot contain more than %d tags.", maxTags))
+	for _, tag := range tags {
+		v.CheckField(validator.MaxChars(tag, 30), "tags", "Tags cannot be more than 30 characters long.")
+		v.CheckField(validator.Matches(tag, tagRX), "tags", "Tags can only contain letters, digits and the characters . + _ -")
+	}
+}
+
+// expiryOption is one of the choices of the "expires" field of the snippet
+// create form.
+type expiryOption struct {
+	Value    string
+	Label    string
+	Duration time.Duration
+}
+
+const (
+	// expiresNever snippets are kept until their owner deletes them.
+	expiresNever = "never"
+	// expiresCustom snippets expire at the date sent in the "expires_at" field.
+	expiresCustom = "custom"
+)
+
+var expiryOptions = []expiryOption{
+	{Value: "10m", Label: "10 Minutes", Duration: 10 * time.Minute},
+	{Value: "1h", Label: "One Hour", Duration: time.Hour},
+	{Value: "1d", Label: "One Day", Duration: 24 * time.Hour},
+	{Value: "7d", Label: "One Week", Duration: 7 * 24 * time.Hour},
+	{Value: "30d", Label: "One Month", Duration: 30 * 24 * time.Hour},
+	{Value: "365d", Label: "One Year", Duration: 365 * 24 * time.Hour},
+	{Value: expiresNever, Label: "Never"},
+	{Value: expiresCustom, Label: "Custom"},
+}
+
+// expiresAtLayout is the format of the "expires_at" field, as sent by a
+// datetime-local input. Like every date shown by the application, it's in UTC.
+const expiresAtLayout = "2006-01-02T15:04"
+
+// maxExpiry is how far in the future a custom expiry date can be.
+const maxExpiry = 10 * 365 * 24 * time.Hour
+
+// checkExpiry validates the "expires" and "expires_at" fields and returns the
+// date the snippet expires at, or nil if it never expires.
+func checkExpiry(v *validator.Validator, option, at string, now time.Time) *time.Time {
+	switch option {
+	case expiresNever:
+		return nil
+	case expiresCustom:
+		expires, err := time.Parse(expiresAtLayout, at)
+		if err != nil {
+			// API clients may send a full RFC 3339 date instead.
+			expires, err = time.Parse(time.RFC3339, at)
+		}
+		if err != nil {
+			v.AddFieldError("expires_at", "This field must be a valid date and time.")
+			return nil
+		}
+		expires = expires.UTC()
+		v.CheckField(expires.After(now), "expires_at", "This field must be in the future.")
+		v.CheckField(expires.Before(now.Add(maxExpiry)), "expires_at", "This field must be within the next 10 years.")
+		return &expires
+	}
 
-	if err := app.errorLog.Output(2, trace); err != nil {
-		app.errorLog.Printf("unable to write to error log: %v", err)
+	for _, o := range expiryOptions {
+		if o.Value == option {
+			expires := now.Add(o.Duration)
+			return &expires
+		}
+	}
+	v.AddFieldError("expires", "This field must be one of the listed expiry options.")
+	return nil
+}
+
+// extensionOptions returns the expiry options the expiry date of a snippet can
+// be extended by, i.e. those with a fixed duration.
+func extensionOptions() []expiryOption {
+	var options []expiryOption
+	for _, o := range expiryOptions {
+		if o.Duration > 0 {
+			options = append(options, o)
+		}
 	}
+	return options
+}
+
+// extensionDuration returns the duration of the extension option with the given
+// value, if there is one.
+func extensionDuration(value string) (time.Duration, bool) {
+	for _, o := range extensionOptions() {
+		if o.Value == value {
+			return o.Duration, true
+		}
+	}
+	return 0, false
+}
+
+// filenameRX matches the runs of characters which aren't allowed in the name of
+// a downloaded archive.
+var filenameRX = regexp.MustCompile(`[^a-z0-9._]+`)
+
+// snippetFileRX matches the names allowed for the files of a snippet.
+var snippetFileRX = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// maxFiles is the maximum number of files a snippet can have.
+const maxFiles = 10
+
+// serverError logs an unexpected error, together with the ID of the request so
+// that it can be matched with the access log, and sends a 500 response. Errors
+// caused by the context of the request are handled by contextError instead.
+func (app *application) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	if app.contextError(w, r, err, app.clientError) {
+		return
+	}
+
+	trace := string(debug.Stack())
+
+	app.logger.Error(err.Error(), "request_id", app.requestID(r), "method", r.Method, "uri", r.URL.RequestURI(), "trace", trace)
 
 	if app.debugEnabled {
-		http.Error(w, trace, http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("%s\n%s", err.Error(), trace), http.StatusInternalServerError)
+		return
 	}
 	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 }
@@ -29,34 +201,68 @@ func (app *application) clientError(w http.ResponseWriter, status int) {
 	http.Error(w, http.StatusText(status), status)
 }
 
-func (app *application) render(w http.ResponseWriter, status int, page string, data *templateData) {
+// contextError handles the errors returned by the models once the context of
+// the request is done, and reports whether err was one of them. A query which
+// ran out of time means that the database is overloaded rather than that
+// something is broken, so the client is told to retry later with a 503 status
+// sent through respond. A cancelled request has no client left to respond to.
+func (app *application) contextError(w http.ResponseWriter, r *http.Request, err error, respond func(w http.ResponseWriter, status int)) bool {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		app.logger.Warn("request timed out", "request_id", app.requestID(r), "method", r.Method, "uri", r.URL.RequestURI(), "error", err)
+		w.Header().Set("Retry-After", "5")
+		respond(w, http.StatusServiceUnavailable)
+		return true
+	case errors.Is(err, context.Canceled):
+		app.logger.Info("request cancelled by the client", "request_id", app.requestID(r), "method", r.Method, "uri", r.URL.RequestURI())
+		return true
+	}
+	return false
+}
+
+func (app *application) render(w http.ResponseWriter, r *http.Request, status int, page string, data *templateData) {
+	buf, err := app.renderPage(page, data)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.writePage(w, r, status, buf)
+}
+
+// renderPage executes the template of the page into a buffer, so that runtime
+// errors in the HTML templates are caught before anything is sent.
+func (app *application) renderPage(page string, data *templateData) (*bytes.Buffer, error) {
 	ts, ok := app.templateCache[page]
 	if !ok {
-		err := fmt.Errorf("the template %s does not exist", page)
-		app.serverError(w, err)
-		return
+		return nil, fmt.Errorf("the template %s does not exist", page)
 	}
 
-	//Initialize a new buffer, to try to execute the template on it (in order to catch runtime errors in HTML templates)
 	buf := new(bytes.Buffer)
+	start := time.Now()
 	if err := ts.ExecuteTemplate(buf, "base", data); err != nil {
-		app.serverError(w, err)
-		return
+		return nil, err
 	}
-	// If the template is written to the buffer without any errors, we are safe
-	// to go ahead and write the HTTP status code to http.ResponseWriter.
+	app.metrics.renderDuration.WithLabelValues(page).Observe(time.Since(start).Seconds())
+
+	return buf, nil
+}
+
+// writePage sends a page rendered by renderPage with the given status code.
+func (app *application) writePage(w http.ResponseWriter, r *http.Request, status int, buf *bytes.Buffer) {
 	w.WriteHeader(status)
 	if _, err := buf.WriteTo(w); err != nil {
-		app.serverError(w, err)
+		app.serverError(w, r, err)
 	}
 }
 
 func (app *application) newTemplateData(r *http.Request) *templateData {
 	return &templateData{
-		CurrentYear:     time.Now().Year(),
-		Flash:           app.sessionManager.PopString(r.Context(), "flash"),
-		IsAuthenticated: app.isAuthenticated(r),
-		CSRFToken:       nosurf.Token(r),
+		CurrentYear:         time.Now().Year(),
+		Flash:               app.sessionManager.PopString(r.Context(), "flash"),
+		IsAuthenticated:     app.isAuthenticated(r),
+		AuthenticatedUserID: app.authenticatedUserID(r),
+		CSRFToken:           nosurf.Token(r),
 	}
 }
 
@@ -83,3 +289,420 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 
 	return isAuthenticated
 }
+
+// authenticatedUserID returns the ID of the currently logged in user, or 0 if
+// the request is not coming from an authenticated user.
+func (app *application) authenticatedUserID(r *http.Request) int {
+	id, ok := r.Context().Value(authenticatedUserIDContextKey).(int)
+	if !ok {
+		return 0
+	}
+
+	return id
+}
+
+// requestID returns the ID assigned to the request by assignRequestID.
+func (app *application) requestID(r *http.Request) string {
+	id, ok := r.Context().Value(requestIDContextKey).(string)
+	if !ok {
+		return ""
+	}
+
+	return id
+}
+
+// hasScope reports whether the request is allowed to perform actions covered by
+// the given API token scope. Requests authenticated with a session cookie are
+// not restricted by scopes.
+func (app *application) hasScope(r *http.Request, scope string) bool {
+	token, ok := r.Context().Value(tokenContextKey).(*models.Token)
+	if !ok {
+		return app.isAuthenticated(r)
+	}
+
+	return token.HasScope(scope)
+}
+
+// readIDParam reads the positive integer ":id" route parameter of the request.
+func (app *application) readIDParam(r *http.Request) (int, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.Atoi(params.ByName("id"))
+	if err != nil || id <= 0 {
+		return 0, errInvalidID
+	}
+
+	return id, nil
+}
+
+// lookupSnippet fetches the snippet referenced by the ":id" route parameter and
+// checks that the current user may see it. See lookupSnippetRef.
+func (app *application) lookupSnippet(r *http.Request) (*models.Snippet, error) {
+	return app.lookupSnippetRef(r, httprouter.ParamsFromContext(r.Context()).ByName("id"))
+}
+
+// readSnippet is like lookupSnippet, but also lets other users read
+// burn-after-reading snippets, which are deleted as soon as they are read. Only
+// the handlers actually showing the snippet use readSnippet, and they must call
+// burnRead once the response is ready, just before sending it, so that the
+// snippet isn't lost if it can't be shown.
+func (app *application) readSnippet(r *http.Request) (*models.Snippet, error) {
+	return app.visibleSnippet(r, httprouter.ParamsFromContext(r.Context()).ByName("id"))
+}
+
+// burnRead deletes a burn-after-reading snippet fetched by readSnippet, unless
+// the current user owns it. If someone else read the snippet first, it's
+// reported as models.ErrNoRecord, and the snippet mustn't be shown.
+func (app *application) burnRead(r *http.Request, snippet *models.Snippet) error {
+	if !snippet.BurnAfterReading || snippet.UserID == app.authenticatedUserID(r) {
+		return nil
+	}
+
+	return app.snippets.Burn(r.Context(), snippet.ID)
+}
+
+// lookupSnippetRef fetches the snippet referenced by ref, and checks that the
+// current user may see it, see visibleSnippet. Burn-after-reading snippets can
+// only be read once, through readSnippet, so to anyone but their owner they're
+// reported as models.ErrNoRecord: they can't be downloaded, forked or compared
+// without being consumed.
+func (app *application) lookupSnippetRef(r *http.Request, ref string) (*models.Snippet, error) {
+	snippet, err := app.visibleSnippet(r, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if snippet.BurnAfterReading && snippet.UserID != app.authenticatedUserID(r) {
+		return nil, models.ErrNoRecord
+	}
+
+	return snippet, nil
+}
+
+// visibleSnippet fetches the snippet referenced by ref, which is either a
+// numeric ID or a slug, and checks that the current user may see it. Snippets
+// the user isn't allowed to see are reported as models.ErrNoRecord, so that
+// their existence isn't revealed. errInvalidID is returned if ref is neither an
+// ID nor a slug.
+func (app *application) visibleSnippet(r *http.Request, ref string) (*models.Snippet, error) {
+	var (
+		snippet *models.Snippet
+		err     error
+	)
+
+	bySlug := slugRX.MatchString(ref)
+	if bySlug {
+		snippet, err = app.snippets.GetBySlug(r.Context(), ref)
+	} else {
+		id, convErr := strconv.Atoi(ref)
+		if convErr != nil || id <= 0 {
+			return nil, errInvalidID
+		}
+		snippet, err = app.snippets.Get(r.Context(), id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !app.canSee(r, snippet, bySlug) {
+		return nil, models.ErrNoRecord
+	}
+
+	return snippet, nil
+}
+
+// canSee reports whether the current user may see the snippet, which was
+// referenced by its slug or by its ID.
+func (app *application) canSee(r *http.Request, snippet *models.Snippet, bySlug bool) bool {
+	// Owners can always see their own snippets, however they are referenced.
+	if snippet.UserID == app.authenticatedUserID(r) {
+		return true
+	}
+
+	switch snippet.Visibility {
+	case models.VisibilityPublic:
+		return true
+	case models.VisibilityUnlisted:
+		// Unlisted snippets are only reachable through their slug, not through
+		// their guessable sequential ID.
+		return bySlug
+	}
+
+	return false
+}
+
+// viewableSnippet wraps lookupSnippet for the HTML handlers. If the snippet
+// can't be shown, an appropriate error response is sent and false is returned.
+func (app *application) viewableSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, err := app.lookupSnippet(r)
+	if err != nil {
+		app.snippetLookupError(w, r, err)
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// readableSnippet is like viewableSnippet, but wraps readSnippet.
+func (app *application) readableSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, err := app.readSnippet(r)
+	if err != nil {
+		app.snippetLookupError(w, r, err)
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// snippetLookupError sends the error response of a failed snippet lookup.
+func (app *application) snippetLookupError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errInvalidID):
+		app.clientError(w, http.StatusBadRequest)
+	case errors.Is(err, models.ErrNoRecord):
+		app.clientError(w, http.StatusNotFound)
+	default:
+		app.serverError(w, r, err)
+	}
+}
+
+// snippetFilename derives the name of a downloaded file of a single-file
+// snippet from its title and language, e.g. "An old silent pond" in Go becomes
+// "an-old-silent-pond.go". The files of multi-file snippets keep their own
+// name, which tells them apart, as do the files of snippets whose title has no
+// usable characters.
+func snippetFilename(snippet *models.Snippet, file *models.File) string {
+	name := titleSlug(snippet.Title)
+	if name == "" || len(snippet.Files) > 1 {
+		return file.Name
+	}
+
+	return name + highlight.Extension(file.Language)
+}
+
+// snippetArchiveName derives the name of the zip archive of a snippet from its
+// title, e.g. "An old silent pond" becomes "an-old-silent-pond.zip".
+func snippetArchiveName(snippet *models.Snippet) string {
+	name := titleSlug(snippet.Title)
+	if name == "" {
+		name = fmt.Sprintf("snippet-%d", snippet.ID)
+	}
+
+	return name + ".zip"
+}
+
+// titleSlug turns a snippet title into a file name, without extension. It
+// returns an empty string if the title has no usable characters.
+func titleSlug(title string) string {
+	name := strings.Trim(filenameRX.ReplaceAllString(strings.ToLower(title), "-"), "-.")
+	if len(name) > 100 {
+		name = strings.TrimRight(name[:100], "-.")
+	}
+
+	return name
+}
+
+// requestedFile returns the file of the snippet named by the "file" query string
+// parameter, or its first file if the parameter is missing. It returns nil if
+// there is no such file.
+func requestedFile(r *http.Request, snippet *models.Snippet) *models.File {
+	if !r.URL.Query().Has("file") {
+		if len(snippet.Files) == 0 {
+			return nil
+		}
+		return snippet.Files[0]
+	}
+	return snippet.File(r.URL.Query().Get("file"))
+}
+
+// zipFiles returns a zip archive containing the given files.
+func zipFiles(files []*models.File) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		fw, err := zw.Create(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(file.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// normalizeFiles trims the names of the submitted files, drops the files left
+// completely blank and names the unnamed ones after their position and language,
+// e.g. "file2.go".
+func normalizeFiles(forms []snippetFileForm) []snippetFileForm {
+	files := []snippetFileForm{}
+	for _, file := range forms {
+		file.Name = strings.TrimSpace(file.Name)
+		if file.Name == "" && strings.TrimSpace(file.Content) == "" {
+			continue
+		}
+		if file.Name == "" {
+			file.Name = fmt.Sprintf("file%d%s", len(files)+1, highlight.Extension(file.Language))
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// checkFiles validates the files of the snippet create and edit forms. Errors
+// about a single file are reported under keys such as "files[0].content".
+func checkFiles(v *validator.Validator, files []snippetFileForm) {
+	v.CheckField(len(files) > 0, "files", "A snippet must have at least one file.")
+	v.CheckField(len(files) <= maxFiles, "files", fmt.Sprintf("A snippet cannot have more than %d files.", maxFiles))
+
+	seen := make(map[string]bool, len(files))
+	for i, file := range files {
+		key := fmt.Sprintf("files[%d].", i)
+		v.CheckField(validator.MaxChars(file.Name, 100), key+"name", "This field cannot be more than 100 characters long.")
+		v.CheckField(validator.Matches(file.Name, snippetFileRX), key+"name", "This field can only contain letters, digits and the characters . _ -")
+		v.CheckField(!seen[file.Name], key+"name", "Every file of a snippet must have a different name.")
+		v.CheckField(validator.PermittedValue(file.Language, highlight.Names()...), key+"language", "This field must be one of the listed languages.")
+		v.CheckField(validator.NotBlank(file.Content), key+"content", "This field cannot be blank.")
+		seen[file.Name] = true
+	}
+}
+
+// toFiles converts the files of a validated form to the snippet files to save.
+func toFiles(forms []snippetFileForm) []*models.File {
+	files := make([]*models.File, len(forms))
+	for i, form := range forms {
+		files[i] = &models.File{Name: form.Name, Language: form.Language, Content: form.Content}
+	}
+	return files
+}
+
+// fileForms converts the files of a snippet to pre-fill a form with them.
+func fileForms(files []*models.File) []snippetFileForm {
+	forms := make([]snippetFileForm, len(files))
+	for i, file := range files {
+		forms[i] = snippetFileForm{Name: file.Name, Language: file.Language, Content: file.Content}
+	}
+	return forms
+}
+
+// findOwnedSnippet fetches the snippet with the given ID and checks that it
+// belongs to the current user. Expired snippets which haven't been purged yet
+// are included. Snippets the user can't see are reported as
+// models.ErrNoRecord, like visibleSnippet does, and errNotOwner is only
+// returned for the snippets they can see but don't own.
+func (app *application) findOwnedSnippet(r *http.Request, id int) (*models.Snippet, error) {
+	snippet, err := app.snippets.Get(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		// Expired snippets can't be fetched with Get anymore, but their owner
+		// can still renew or delete them until they are purged.
+		snippet, err = app.snippets.GetOwned(r.Context(), id, app.authenticatedUserID(r))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if snippet.UserID != app.authenticatedUserID(r) {
+		// Burn-after-reading snippets can't be seen without being read, see
+		// lookupSnippetRef.
+		if !app.canSee(r, snippet, false) || snippet.BurnAfterReading {
+			return nil, models.ErrNoRecord
+		}
+		return nil, errNotOwner
+	}
+
+	return snippet, nil
+}
+
+// ownedSnippet wraps findOwnedSnippet for the HTML handlers, with the snippet
+// identified by the ":id" route parameter. If the snippet isn't owned by the
+// current user, an appropriate error response is sent and false is returned, so
+// the caller only has to return. See also editableSnippet.
+func (app *application) ownedSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return nil, false
+	}
+
+	snippet, err := app.findOwnedSnippet(r, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.clientError(w, http.StatusNotFound)
+		case errors.Is(err, errNotOwner):
+			app.clientError(w, http.StatusForbidden)
+		default:
+			app.serverError(w, r, err)
+		}
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// editableSnippet is like ownedSnippet, but reports expired snippets as not
+// found: they can only be renewed or deleted, not edited.
+func (app *application) editableSnippet(w http.ResponseWriter, r *http.Request) (*models.Snippet, bool) {
+	snippet, ok := app.ownedSnippet(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if snippet.Expired() {
+		app.clientError(w, http.StatusNotFound)
+		return nil, false
+	}
+
+	return snippet, true
+}
+
+// renderTokens renders the API tokens page, listing the tokens of the current
+// user together with the given token creation form.
+func (app *application) renderTokens(w http.ResponseWriter, r *http.Request, status int, form tokenCreateForm) {
+	tokens, err := app.tokens.AllForUser(r.Context(), app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tokens = tokens
+	data.NewToken = app.sessionManager.PopString(r.Context(), "newToken")
+	data.Form = form
+	app.render(w, r, status, "tokens.tmpl.html", data)
+}
+
+// readInt reads an integer value from the query string. If the key isn't present
+// the provided default value is returned; if it can't be converted to an integer
+// an error message is recorded in the validator.
+func (app *application) readInt(qs url.Values, key string, defaultValue int, v *validator.Validator) int {
+	value := qs.Get(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		v.AddFieldError(key, "This field must be an integer value.")
+		return defaultValue
+	}
+
+	return i
+}
+
+// readFilters reads the "page" and "limit" pagination parameters from the query
+// string and validates them.
+func (app *application) readFilters(qs url.Values, defaultPageSize int, v *validator.Validator) models.Filters {
+	filters := models.Filters{
+		Page:     app.readInt(qs, "page", 1, v),
+		PageSize: app.readInt(qs, "limit", defaultPageSize, v),
+	}
+
+	v.CheckField(validator.Between(filters.Page, 1, 10_000_000), "page", "This field must be between 1 and 10 million.")
+	v.CheckField(validator.Between(filters.PageSize, 1, 100), "limit", "This field must be between 1 and 100.")
+
+	return filters
+}
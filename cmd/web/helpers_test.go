@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+	"github.com/vladComan0/go-snippets/internal/models"
+	"github.com/vladComan0/go-snippets/internal/models/mocks"
+	"github.com/vladComan0/go-snippets/internal/validator"
+)
+
+func TestSnippetFilename(t *testing.T) {
+	goFile := &models.File{Name: "main.go", Language: "go"}
+
+	tests := []struct {
+		name     string
+		snippet  *models.Snippet
+		file     *models.File
+		expected string
+	}{
+		{
+			name:     "Title and language",
+			snippet:  &models.Snippet{ID: 1, Title: "An old silent pond", Files: []*models.File{goFile}},
+			file:     goFile,
+			expected: "an-old-silent-pond.go",
+		},
+		{
+			name:     "Special characters",
+			snippet:  &models.Snippet{ID: 1, Title: "../Hello, World!", Files: []*models.File{goFile}},
+			file:     &models.File{Name: "hello.py", Language: "python"},
+			expected: "hello-world.py",
+		},
+		{
+			name:     "Keeps dots and underscores",
+			snippet:  &models.Snippet{ID: 1, Title: "docker_compose.prod", Files: []*models.File{goFile}},
+			file:     &models.File{Name: "compose.yml", Language: "yaml"},
+			expected: "docker_compose.prod.yaml",
+		},
+		{
+			name:     "No usable characters",
+			snippet:  &models.Snippet{ID: 7, Title: "古池や", Files: []*models.File{goFile}},
+			file:     goFile,
+			expected: "main.go",
+		},
+		{
+			name:     "Multi-file snippet",
+			snippet:  &models.Snippet{ID: 1, Title: "An old silent pond", Files: []*models.File{goFile, {Name: "go.mod"}}},
+			file:     goFile,
+			expected: "main.go",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual := snippetFilename(subtest.snippet, subtest.file)
+			assert.Equal(t, actual, subtest.expected)
+		})
+	}
+}
+
+func TestSnippetArchiveName(t *testing.T) {
+	tests := []struct {
+		name     string
+		snippet  *models.Snippet
+		expected string
+	}{
+		{
+			name:     "Title",
+			snippet:  &models.Snippet{ID: 1, Title: "An old silent pond"},
+			expected: "an-old-silent-pond.zip",
+		},
+		{
+			name:     "Special characters",
+			snippet:  &models.Snippet{ID: 1, Title: "../Hello, World!"},
+			expected: "hello-world.zip",
+		},
+		{
+			name:     "Keeps dots and underscores",
+			snippet:  &models.Snippet{ID: 1, Title: "docker_compose.prod"},
+			expected: "docker_compose.prod.zip",
+		},
+		{
+			name:     "No usable characters",
+			snippet:  &models.Snippet{ID: 7, Title: "古池や"},
+			expected: "snippet-7.zip",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual := snippetArchiveName(subtest.snippet)
+			assert.Equal(t, actual, subtest.expected)
+		})
+	}
+}
+
+func TestNormalizeFiles(t *testing.T) {
+	files := normalizeFiles([]snippetFileForm{
+		{Name: " main.go ", Language: "go", Content: "package main"},
+		{Name: "", Language: "go", Content: " "},
+		{Name: "", Language: "yaml", Content: "key: value"},
+		{Name: "", Language: "bash", Content: "echo hello"},
+	})
+
+	assert.Equal(t, len(files), 3)
+	assert.Equal(t, files[0].Name, "main.go")
+	assert.Equal(t, files[1].Name, "file2.yaml")
+	assert.Equal(t, files[2].Name, "file3.sh")
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "Empty",
+			value:    "",
+			expected: "",
+		},
+		{
+			name:     "Trims and lowercases",
+			value:    " Go ,HTTP, testing ",
+			expected: "go|http|testing",
+		},
+		{
+			name:     "Drops blanks and duplicates",
+			value:    "go,, go ,Go,http,",
+			expected: "go|http",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual := strings.Join(parseTags(subtest.value), "|")
+			assert.Equal(t, actual, subtest.expected)
+		})
+	}
+}
+
+func TestCheckExpiry(t *testing.T) {
+	now := time.Date(2024, 3, 17, 10, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		option    string
+		at        string
+		expected  string
+		wantError string
+	}{
+		{
+			name:     "Duration",
+			option:   "10m",
+			expected: "2024-03-17T10:25:00Z",
+		},
+		{
+			name:     "Never",
+			option:   "never",
+			expected: "",
+		},
+		{
+			name:     "Custom",
+			option:   "custom",
+			at:       "2024-12-25T08:00",
+			expected: "2024-12-25T08:00:00Z",
+		},
+		{
+			name:     "Custom RFC 3339",
+			option:   "custom",
+			at:       "2024-12-25T09:00:00+01:00",
+			expected: "2024-12-25T08:00:00Z",
+		},
+		{
+			name:      "Custom in the past",
+			option:    "custom",
+			at:        "2024-03-17T10:00",
+			expected:  "2024-03-17T10:00:00Z",
+			wantError: "expires_at",
+		},
+		{
+			name:      "Custom too far away",
+			option:    "custom",
+			at:        "2099-01-01T10:00",
+			expected:  "2099-01-01T10:00:00Z",
+			wantError: "expires_at",
+		},
+		{
+			name:      "Invalid custom",
+			option:    "custom",
+			at:        "tomorrow",
+			wantError: "expires_at",
+		},
+		{
+			name:      "Unknown option",
+			option:    "3",
+			wantError: "expires",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			var v validator.Validator
+			expires := checkExpiry(&v, subtest.option, subtest.at, now)
+
+			actual := ""
+			if expires != nil {
+				actual = expires.Format(time.RFC3339)
+			}
+			assert.Equal(t, actual, subtest.expected)
+
+			_, hasError := v.FieldErrors[subtest.wantError]
+			assert.Equal(t, hasError, subtest.wantError != "")
+		})
+	}
+}
+
+// slowSnippets is a snippet model whose queries fail with err.
+type slowSnippets struct {
+	mocks.SnippetModel
+	err error
+}
+
+func (m *slowSnippets) Get(ctx context.Context, id int) (*models.Snippet, error) {
+	return nil, m.err
+}
+
+func TestContextError(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlPath        string
+		err            error
+		wantCode       int
+		wantRetryAfter string
+		wantBody       string
+	}{
+		{
+			name:           "Timeout",
+			urlPath:        "/snippet/view/1",
+			err:            fmt.Errorf("loading snippet: %w", context.DeadlineExceeded),
+			wantCode:       http.StatusServiceUnavailable,
+			wantRetryAfter: "5",
+			wantBody:       "Service Unavailable",
+		},
+		{
+			name:           "API timeout",
+			urlPath:        "/api/v1/snippets/1",
+			err:            context.DeadlineExceeded,
+			wantCode:       http.StatusServiceUnavailable,
+			wantRetryAfter: "5",
+			wantBody:       `"message": "service unavailable"`,
+		},
+		{
+			name:     "Other errors",
+			urlPath:  "/snippet/view/1",
+			err:      errors.New("connection refused"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: "Internal Server Error",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.snippets = &slowSnippets{err: subtest.err}
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			code, headers, body := ts.get(t, subtest.urlPath)
+
+			assert.Equal(t, code, subtest.wantCode)
+			assert.Equal(t, headers.Get("Retry-After"), subtest.wantRetryAfter)
+			assert.StringContains(t, body, subtest.wantBody)
+		})
+	}
+
+	t.Run("Cancelled", func(t *testing.T) {
+		app := newTestApplication(t)
+
+		rr := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/snippet/view/1", nil)
+		app.serverError(rr, r, context.Canceled)
+
+		// Nobody is left to read a response.
+		assert.Equal(t, rr.Body.Len(), 0)
+	})
+}
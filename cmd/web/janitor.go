@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// runJanitor purges the expired snippets every interval until ctx is cancelled.
+// Expired sessions are purged by the session store, which shares the interval.
+func (app *application) runJanitor(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := app.purgeExpiredSnippets(ctx, batchSize)
+		// Errors caused by the application stopping mid-batch aren't worth logging.
+		if err != nil && !errors.Is(err, context.Canceled) {
+			app.logger.Error("janitor: purging expired snippets", "error", err)
+		}
+		if deleted > 0 {
+			app.logger.Info("janitor: purged expired snippets", "count", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpiredSnippets deletes the expired snippets in batches of batchSize, so
+// that a large backlog doesn't lock the snippets table for long, and returns how
+// many were deleted. It stops after the current batch if ctx is cancelled.
+func (app *application) purgeExpiredSnippets(ctx context.Context, batchSize int) (int, error) {
+	total := 0
+	for {
+		deleted, err := app.snippets.DeleteExpired(ctx, batchSize)
+		total += deleted
+		if err != nil {
+			return total, err
+		}
+		if deleted < batchSize || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+	"github.com/vladComan0/go-snippets/internal/models/mocks"
+)
+
+// expiredSnippets is a snippet model holding a number of expired snippets.
+type expiredSnippets struct {
+	mocks.SnippetModel
+	remaining int
+	calls     int
+	err       error
+}
+
+func (m *expiredSnippets) DeleteExpired(ctx context.Context, batchSize int) (int, error) {
+	m.calls++
+	if m.err != nil {
+		return 0, m.err
+	}
+	deleted := min(batchSize, m.remaining)
+	m.remaining -= deleted
+	return deleted, nil
+}
+
+func TestPurgeExpiredSnippets(t *testing.T) {
+	tests := []struct {
+		name        string
+		remaining   int
+		err         error
+		wantDeleted int
+		wantCalls   int
+	}{
+		{
+			name:        "Nothing to purge",
+			remaining:   0,
+			wantDeleted: 0,
+			wantCalls:   1,
+		},
+		{
+			name:        "Single batch",
+			remaining:   7,
+			wantDeleted: 7,
+			wantCalls:   1,
+		},
+		{
+			name:        "Several batches",
+			remaining:   25,
+			wantDeleted: 25,
+			wantCalls:   3,
+		},
+		{
+			name:        "Exact batches",
+			remaining:   20,
+			wantDeleted: 20,
+			wantCalls:   3,
+		},
+		{
+			name:      "Database error",
+			remaining: 25,
+			err:       errors.New("connection refused"),
+			wantCalls: 1,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			snippets := &expiredSnippets{remaining: subtest.remaining, err: subtest.err}
+			app := newTestApplication(t)
+			app.snippets = snippets
+
+			deleted, err := app.purgeExpiredSnippets(context.Background(), 10)
+
+			assert.Equal(t, errors.Is(err, subtest.err), true)
+			assert.Equal(t, deleted, subtest.wantDeleted)
+			assert.Equal(t, snippets.calls, subtest.wantCalls)
+		})
+	}
+}
+
+func TestRunJanitor(t *testing.T) {
+	snippets := &expiredSnippets{remaining: 5}
+	app := newTestApplication(t)
+	app.snippets = snippets
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		app.runJanitor(ctx, time.Hour, 10)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("janitor didn't stop after its context was cancelled")
+	}
+	assert.Equal(t, snippets.remaining, 0)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes the names of all the application metrics.
+const metricsNamespace = "snippetbox"
+
+// metrics holds the Prometheus collectors of the application. Each application
+// gets its own registry, rather than using the global default one, so that the
+// tests don't share their counters.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requests             *prometheus.CounterVec
+	requestDuration      *prometheus.HistogramVec
+	renderDuration       *prometheus.HistogramVec
+	passwordHashDuration *prometheus.HistogramVec
+	snippetsCreated      *prometheus.CounterVec
+	snippetsViewed       *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests served, by route pattern, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by route pattern and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		renderDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "template_render_duration_seconds",
+			Help:      "Time taken to execute the HTML templates, by page.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25},
+		}, []string{"page"}),
+		passwordHashDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "password_hash_duration_seconds",
+			Help:      "Time taken by bcrypt to generate or compare password hashes.",
+			Buckets:   []float64{.05, .1, .2, .3, .5, .75, 1, 2},
+		}, []string{"operation"}),
+		snippetsCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "snippets_created_total",
+			Help:      "Number of snippets created, through the web interface or the API.",
+		}, []string{"interface"}),
+		snippetsViewed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "snippets_viewed_total",
+			Help:      "Number of snippets viewed, through the web interface or the API.",
+		}, []string{"interface"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.renderDuration,
+		m.passwordHashDuration,
+		m.snippetsCreated,
+		m.snippetsViewed,
+	)
+
+	return m
+}
+
+// observeRequest records a served HTTP request. Requests which didn't match any
+// route are grouped together, so that scanners probing random URLs can't blow up
+// the number of series.
+func (m *metrics) observeRequest(route, method string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	m.requests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// observePasswordHash records the duration of a bcrypt operation, see
+// models.UserModel.
+func (m *metrics) observePasswordHash(operation string, duration time.Duration) {
+	m.passwordHashDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// serveMetrics serves the metrics and health checks on the admin listener srv
+// until ctx is cancelled. The admin listener is plain HTTP, and is meant to be reachable
+// only from the Prometheus server, never from the internet.
+func (app *application) serveMetrics(ctx context.Context, srv *http.Server) error {
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownErr <- srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-shutdownErr
+}
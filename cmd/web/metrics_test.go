@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	ts.get(t, "/snippet/view/1")
+	ts.get(t, "/snippet/view/1")
+	ts.get(t, "/no/such/page")
+	ts.get(t, "/api/v1/snippets/1")
+
+	rr := httptest.NewRecorder()
+	app.metrics.handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, rr.Code, http.StatusOK)
+	body := rr.Body.String()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			name: "Requests by route",
+			want: `snippetbox_http_requests_total{method="GET",route="/snippet/view/:id",status="200"} 2`,
+		},
+		{
+			name: "Unmatched requests",
+			want: `snippetbox_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		},
+		{
+			name: "Request durations",
+			want: `snippetbox_http_request_duration_seconds_count{method="GET",route="/snippet/view/:id"} 2`,
+		},
+		{
+			name: "Render durations",
+			want: `snippetbox_template_render_duration_seconds_count{page="view.tmpl.html"} 2`,
+		},
+		{
+			name: "Snippets viewed on the web",
+			want: `snippetbox_snippets_viewed_total{interface="web"} 2`,
+		},
+		{
+			name: "Snippets viewed through the API",
+			want: `snippetbox_snippets_viewed_total{interface="api"} 1`,
+		},
+		{
+			name: "Go runtime",
+			want: "go_goroutines",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			assert.StringContains(t, body, subtest.want)
+		})
+	}
+}
@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/justinas/nosurf"
+	"github.com/vladComan0/go-snippets/internal/models"
 )
 
 func secureHeaders(next http.Handler) http.Handler {
@@ -19,9 +27,103 @@ func secureHeaders(next http.Handler) http.Handler {
 	})
 }
 
+// requestIDRX matches the request IDs accepted from clients, or from a proxy in
+// front of the application.
+var requestIDRX = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// assignRequestID identifies every request with the ID in its X-Request-ID
+// header, or a new random one if it has none, and sends it back in the same
+// response header.
+func assignRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !requestIDRX.MatchString(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newRequestID returns a random 128-bit request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Uniqueness matters more than randomness for request IDs.
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder records the status code and size of a response for the
+// access log.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// logRequests writes an access log record, and updates the request metrics, once
+// every request has been served. The route pattern and authenticated user are
+// filled in further down the chain, see requestInfo.
 func (app *application) logRequests(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		app.infoLog.Printf("%s - %s %s %s", r.RemoteAddr, r.Proto, r.Method, r.URL.RequestURI())
+		start := time.Now()
+		info := &requestInfo{}
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		ctx := context.WithValue(r.Context(), requestInfoContextKey, info)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+		duration := time.Since(start)
+
+		app.metrics.observeRequest(info.route, r.Method, rw.status, duration)
+		app.logger.Info("request",
+			"request_id", app.requestID(r),
+			"remote_addr", r.RemoteAddr,
+			"proto", r.Proto,
+			"method", r.Method,
+			"uri", r.URL.RequestURI(),
+			"route", info.route,
+			"status", rw.status,
+			"bytes", rw.bytes,
+			"duration", duration,
+			"user_id", info.userID,
+		)
+	})
+}
+
+// recordRoute reports the route pattern a request was matched with to the
+// access log.
+func recordRoute(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(requestInfoContextKey).(*requestInfo); ok {
+			info.route = pattern
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -35,7 +137,7 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 			// panic or not.
 			if err := recover(); err != nil {
 				w.Header().Set("Connection", "close")
-				app.serverError(w, fmt.Errorf("%s", err))
+				app.serverError(w, r, fmt.Errorf("%s", err))
 			}
 		}()
 		next.ServeHTTP(w, r)
@@ -57,6 +159,20 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+// requireAPIAuthentication is the JSON API counterpart of requireAuthentication:
+// instead of redirecting to the login page it responds with a 401 error.
+func (app *application) requireAPIAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			app.apiClientError(w, http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Add("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
+
 // Create a NoSurf middleware function which uses a customized CSRF cookie with
 // the Secure, Path and HttpOnly attributes set.
 func noSurf(next http.Handler) http.Handler {
@@ -70,9 +186,11 @@ func noSurf(next http.Handler) http.Handler {
 	return csrfHandler
 }
 
+// authenticate identifies the user of the HTML routes from their session. It
+// ignores the Authorization header: API tokens are only accepted by the JSON API,
+// where their scopes are enforced, see authenticateAPI.
 func (app *application) authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-
 		// Retrieve the authenticatedUserID value from the session using the
 		// GetInt() method. This will return the zero value for an int (0) if no
 		// "authenticatedUserID" value is in the session -- in which case we
@@ -84,21 +202,105 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		}
 
 		// Check to see if the user ID exists in the database.
-		exists, err := app.users.Exists(id)
+		exists, err := app.users.Exists(r.Context(), id)
 		if err != nil {
-			app.serverError(w, err)
+			app.serverError(w, r, err)
 			return
 		}
 
 		// If a matching user is found -> the request is coming from an authenticated user
 		// who exists in our database.
-		// Create a new copy of the request (with an isAuthenticatedContextKey value of true in the request context)
-		// and assign it to r.
+		// Create a new copy of the request (with an isAuthenticatedContextKey value of true
+		// and the ID of the user in the request context) and assign it to r.
 		if exists {
 			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+			ctx = context.WithValue(ctx, authenticatedUserIDContextKey, id)
 			r = r.WithContext(ctx)
+			recordUser(r, id)
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// authenticateAPI is the JSON API counterpart of authenticate. Non-browser
+// clients authenticate with a personal API token sent in an
+// "Authorization: Bearer <token>" header instead of a session cookie.
+func (app *application) authenticateAPI(next http.Handler) http.Handler {
+	sessionAuthenticated := app.authenticate(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorizationHeader := r.Header.Get("Authorization"); authorizationHeader != "" {
+			app.authenticateToken(next, w, r, authorizationHeader)
+			return
+		}
+
+		sessionAuthenticated.ServeHTTP(w, r)
+	})
+}
+
+// authenticateToken handles the bearer token branch of the authenticateAPI middleware.
+// A malformed, unknown or expired token is rejected outright rather than treated
+// as an anonymous request, so clients notice that their token no longer works.
+func (app *application) authenticateToken(next http.Handler, w http.ResponseWriter, r *http.Request, authorizationHeader string) {
+	w.Header().Add("Vary", "Authorization")
+
+	plaintext, found := strings.CutPrefix(authorizationHeader, "Bearer ")
+	if !found || len(plaintext) != models.TOKEN_LENGTH {
+		app.invalidAuthenticationToken(w)
+		return
+	}
+
+	token, err := app.tokens.GetForToken(r.Context(), plaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+			app.invalidAuthenticationToken(w)
+		default:
+			app.apiServerError(w, r, err)
+		}
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, true)
+	ctx = context.WithValue(ctx, authenticatedUserIDContextKey, token.UserID)
+	ctx = context.WithValue(ctx, tokenContextKey, token)
+	recordUser(r, token.UserID)
+
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// recordUser reports the authenticated user of a request to the access log.
+func recordUser(r *http.Request, userID int) {
+	if info, ok := r.Context().Value(requestInfoContextKey).(*requestInfo); ok {
+		info.userID = userID
+	}
+}
+
+// requireScope rejects API requests authenticated with a token which hasn't been
+// granted the given scope.
+func (app *application) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !app.hasScope(r, scope) {
+			app.apiErrorResponse(w, http.StatusForbidden, fmt.Sprintf("this token is missing the %q scope", scope), nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// anonymousWithoutScope serves the API requests authenticated with a token which
+// hasn't been granted the given scope as anonymous requests, so that the token
+// only reads what everybody can.
+func (app *application) anonymousWithoutScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := r.Context().Value(tokenContextKey).(*models.Token); ok && !token.HasScope(scope) {
+			ctx := context.WithValue(r.Context(), isAuthenticatedContextKey, false)
+			ctx = context.WithValue(ctx, authenticatedUserIDContextKey, 0)
+			r = r.WithContext(ctx)
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -99,3 +101,81 @@ func TestSecureHeaders(t *testing.T) {
 	bytes.TrimSpace(responseBody)
 	assert.Equal(t, string(responseBody), "OK")
 }
+
+func TestAssignRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		wantSame  bool
+	}{
+		{
+			name:      "Propagated",
+			requestID: "7f3c2a9e-req.42",
+			wantSame:  true,
+		},
+		{
+			name:      "Missing",
+			requestID: "",
+		},
+		{
+			name:      "Invalid",
+			requestID: "<script>",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			app := newTestApplication(t)
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if subtest.requestID != "" {
+				request.Header.Set("X-Request-ID", subtest.requestID)
+			}
+
+			var seen string
+			handler := assignRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = app.requestID(r)
+			}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, request)
+
+			assert.Equal(t, rr.Header().Get("X-Request-ID"), seen)
+			assert.Equal(t, seen == subtest.requestID, subtest.wantSame)
+			assert.Equal(t, requestIDRX.MatchString(seen), true)
+		})
+	}
+}
+
+func TestLogRequests(t *testing.T) {
+	app := newTestApplication(t)
+
+	var logs bytes.Buffer
+	app.logger = slog.New(slog.NewJSONHandler(&logs, nil))
+
+	request := httptest.NewRequest(http.MethodGet, "/snippet/view/1", nil)
+	request.Header.Set("X-Request-ID", "access-log-test")
+
+	rr := httptest.NewRecorder()
+	app.routes().ServeHTTP(rr, request)
+
+	var record struct {
+		Msg       string `json:"msg"`
+		RequestID string `json:"request_id"`
+		Method    string `json:"method"`
+		Route     string `json:"route"`
+		Status    int    `json:"status"`
+		Bytes     int    `json:"bytes"`
+		UserID    int    `json:"user_id"`
+	}
+	err := json.Unmarshal(logs.Bytes(), &record)
+	assert.NilError(t, err)
+
+	assert.Equal(t, record.Msg, "request")
+	assert.Equal(t, record.RequestID, "access-log-test")
+	assert.Equal(t, record.Method, http.MethodGet)
+	assert.Equal(t, record.Route, "/snippet/view/:id")
+	assert.Equal(t, record.Status, http.StatusOK)
+	assert.Equal(t, record.Bytes, rr.Body.Len())
+	assert.Equal(t, record.UserID, 0)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/models"
+)
+
+const migrateUsage = "usage: web [flags] migrate up | down [steps] | status"
+
+// runMigrate runs the migrate subcommand with its arguments, reporting to w:
+//
+//	up            applies all the pending migrations
+//	down [steps]  reverts the last steps applied migrations, 1 by default
+//	status        lists the migrations and when they were applied
+func runMigrate(ctx context.Context, migrator *models.Migrator, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch command := args[0]; {
+	case command == "up" && len(args) == 1:
+		done, err := migrator.Up(ctx)
+		for _, migration := range done {
+			fmt.Fprintf(w, "applied %s\n", migration)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(w, "no pending migrations")
+		}
+		return err
+
+	case command == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid number of steps %q, %s", args[1], migrateUsage)
+			}
+			steps = n
+		}
+		done, err := migrator.Down(ctx, steps)
+		for _, migration := range done {
+			fmt.Fprintf(w, "reverted %s\n", migration)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Fprintln(w, "no applied migrations")
+		}
+		return err
+
+	case command == "status" && len(args) == 1:
+		migrations, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "MIGRATION\tAPPLIED")
+		for _, migration := range migrations {
+			applied := "pending"
+			if !migration.Applied.IsZero() {
+				applied = migration.Applied.UTC().Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", migration, applied)
+		}
+		return tw.Flush()
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
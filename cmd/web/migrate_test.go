@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+	"github.com/vladComan0/go-snippets/internal/models"
+)
+
+func TestRunMigrate(t *testing.T) {
+	dialect, err := models.DialectFor(models.DriverSQLite)
+	assert.NilError(t, err)
+
+	db, err := sql.Open(dialect.DriverName(), "file:"+filepath.Join(t.TempDir(), "snippetbox.db")+"?_pragma=foreign_keys(1)&_time_format=sqlite")
+	assert.NilError(t, err)
+	defer db.Close()
+
+	migrator := &models.Migrator{DB: db, Dialect: dialect}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantOut string
+		wantErr string
+	}{
+		{
+			name:    "Pending status",
+			args:    []string{"status"},
+			wantOut: "0001_create_sessions                  pending",
+		},
+		{
+			name:    "Up",
+			args:    []string{"up"},
+			wantOut: "applied 0001_create_sessions\napplied 0002_create_snippets\n",
+		},
+		{
+			name:    "Up to date",
+			args:    []string{"up"},
+			wantOut: "no pending migrations",
+		},
+		{
+			name:    "Down",
+			args:    []string{"down"},
+			wantOut: "reverted 0013_index_snippet_expiry\n",
+		},
+		{
+			name:    "Status after down",
+			args:    []string{"status"},
+			wantOut: "0013_index_snippet_expiry             pending",
+		},
+		{
+			name:    "Down all",
+			args:    []string{"down", "20"},
+			wantOut: "reverted 0001_create_sessions\n",
+		},
+		{
+			name:    "Invalid steps",
+			args:    []string{"down", "zero"},
+			wantErr: `invalid number of steps "zero"`,
+		},
+		{
+			name:    "Unknown command",
+			args:    []string{"sideways"},
+			wantErr: migrateUsage,
+		},
+		{
+			name:    "No command",
+			wantErr: migrateUsage,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := runMigrate(context.Background(), migrator, subtest.args, &out)
+			if subtest.wantErr != "" {
+				assert.StringContains(t, err.Error(), subtest.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.StringContains(t, out.String(), subtest.wantOut)
+		})
+	}
+}
@@ -2,57 +2,120 @@ package main
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
+	"github.com/vladComan0/go-snippets/internal/models"
 	"github.com/vladComan0/go-snippets/ui"
 )
 
 func (app *application) routes() http.Handler {
 	router := httprouter.New()
 
+	// handle registers a route, recording its pattern for the access log.
+	handle := func(method, pattern string, handler http.Handler) {
+		router.Handler(method, pattern, recordRoute(pattern, handler))
+	}
+
 	// Convert the ui.Files embedded filesystem into an http.FileServer to satisfy the http.FileSystem interface
 	fileServer := http.FileServer(http.FS(ui.Files))
 
 	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			app.apiClientError(w, http.StatusNotFound)
+			return
+		}
 		app.clientError(w, http.StatusNotFound)
 	})
 
+	router.MethodNotAllowed = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			app.apiClientError(w, http.StatusMethodNotAllowed)
+			return
+		}
+		app.clientError(w, http.StatusMethodNotAllowed)
+	})
+
 	// CSS server route
-	router.Handler(http.MethodGet, "/static/*filepath", fileServer)
+	handle(http.MethodGet, "/static/*filepath", fileServer)
 
-	router.HandlerFunc(http.MethodGet, "/ping", app.ping)
+	handle(http.MethodGet, "/ping", http.HandlerFunc(app.ping))
+	handle(http.MethodGet, "/healthz", http.HandlerFunc(app.healthz))
+	handle(http.MethodGet, "/readyz", http.HandlerFunc(app.readyz))
 	// CRUD + Authentication routes
 
 	// Create a new middleware chain containing the middleware specific to our dynamic application routes.
 	dynamicChain := alice.New(app.sessionManager.LoadAndSave, noSurf, app.authenticate)
 
 	// Unprotected (with respect to authotization) application routes that use the "dynamic" middleware chain.
-	router.Handler(http.MethodGet, "/", dynamicChain.ThenFunc(app.home))
-	router.Handler(http.MethodGet, "/snippet/view/:id", dynamicChain.ThenFunc(app.snippetView))
-	router.Handler(http.MethodGet, "/about", dynamicChain.ThenFunc(app.about))
+	handle(http.MethodGet, "/", dynamicChain.ThenFunc(app.home))
+	handle(http.MethodGet, "/snippet/view/:id", dynamicChain.ThenFunc(app.snippetView))
+	handle(http.MethodGet, "/snippet/view/:id/history", dynamicChain.ThenFunc(app.snippetHistory))
+	handle(http.MethodGet, "/snippet/view/:id/diff", dynamicChain.ThenFunc(app.snippetDiff))
+	handle(http.MethodGet, "/snippet/raw/:id", dynamicChain.ThenFunc(app.snippetRaw))
+	handle(http.MethodGet, "/snippet/download/:id", dynamicChain.ThenFunc(app.snippetDownload))
+	handle(http.MethodGet, "/snippet/search", dynamicChain.ThenFunc(app.snippetSearch))
+	handle(http.MethodGet, "/tag/:name", dynamicChain.ThenFunc(app.snippetTag))
+	handle(http.MethodGet, "/about", dynamicChain.ThenFunc(app.about))
 
-	router.Handler(http.MethodGet, "/user/signup", dynamicChain.ThenFunc(app.userSignup))
-	router.Handler(http.MethodPost, "/user/signup", dynamicChain.ThenFunc(app.userSignupPost))
-	router.Handler(http.MethodGet, "/user/login", dynamicChain.ThenFunc(app.userLogin))
-	router.Handler(http.MethodPost, "/user/login", dynamicChain.ThenFunc(app.userLoginPost))
+	handle(http.MethodGet, "/user/signup", dynamicChain.ThenFunc(app.userSignup))
+	handle(http.MethodPost, "/user/signup", dynamicChain.ThenFunc(app.userSignupPost))
+	handle(http.MethodGet, "/user/login", dynamicChain.ThenFunc(app.userLogin))
+	handle(http.MethodPost, "/user/login", dynamicChain.ThenFunc(app.userLoginPost))
 
 	// Create a new middleware chain containing the middleware specific to our dynamic application routes
 	// AND the "requireAuthentication" middleware.
 	protectedChain := dynamicChain.Append(app.requireAuthentication)
 
 	// Protected (with respect to authorization) application routes that use the protected middleware chain.
-	router.Handler(http.MethodGet, "/snippet/create", protectedChain.ThenFunc(app.snippetCreate))
-	router.Handler(http.MethodPost, "/snippet/create", protectedChain.ThenFunc(app.snippetCreatePost))
-	router.Handler(http.MethodGet, "/account/view", protectedChain.ThenFunc(app.accountView))
-	router.Handler(http.MethodGet, "/account/password/update", protectedChain.ThenFunc(app.accountPasswordUpdate))
-	router.Handler(http.MethodPost, "/account/password/update", protectedChain.ThenFunc(app.accountPasswordUpdatePost))
+	handle(http.MethodGet, "/snippet/create", protectedChain.ThenFunc(app.snippetCreate))
+	handle(http.MethodPost, "/snippet/create", protectedChain.ThenFunc(app.snippetCreatePost))
+	handle(http.MethodGet, "/snippet/fork/:id", protectedChain.ThenFunc(app.snippetFork))
+	handle(http.MethodGet, "/snippet/edit/:id", protectedChain.ThenFunc(app.snippetEdit))
+	handle(http.MethodPost, "/snippet/edit/:id", protectedChain.ThenFunc(app.snippetEditPost))
+	handle(http.MethodPost, "/snippet/restore/:id", protectedChain.ThenFunc(app.snippetRestorePost))
+	handle(http.MethodPost, "/snippet/extend/:id", protectedChain.ThenFunc(app.snippetExtendPost))
+	handle(http.MethodPost, "/snippet/delete/:id", protectedChain.ThenFunc(app.snippetDeletePost))
+	handle(http.MethodGet, "/account/view", protectedChain.ThenFunc(app.accountView))
+	handle(http.MethodGet, "/account/password/update", protectedChain.ThenFunc(app.accountPasswordUpdate))
+	handle(http.MethodPost, "/account/password/update", protectedChain.ThenFunc(app.accountPasswordUpdatePost))
+	handle(http.MethodGet, "/account/tokens", protectedChain.ThenFunc(app.accountTokens))
+	handle(http.MethodPost, "/account/tokens", protectedChain.ThenFunc(app.accountTokensPost))
+	handle(http.MethodPost, "/account/tokens/revoke/:id", protectedChain.ThenFunc(app.accountTokenRevokePost))
+
+	handle(http.MethodPost, "/user/logout", protectedChain.ThenFunc(app.userLogoutPost))
 
-	router.Handler(http.MethodPost, "/user/logout", protectedChain.ThenFunc(app.userLogoutPost))
+	// The JSON API doesn't use the nosurf middleware. Its state-changing endpoints
+	// either use PUT/DELETE or require an "application/json" body, both of which
+	// make browsers send a CORS preflight for cross-site requests, and we never
+	// approve those.
+	apiChain := alice.New(app.sessionManager.LoadAndSave, app.authenticateAPI)
+	protectedAPIChain := apiChain.Append(app.requireAPIAuthentication)
+
+	handle(http.MethodGet, "/api/v1/snippets", apiChain.ThenFunc(app.anonymousWithoutScope(models.ScopeSnippetsRead, app.apiSnippetList)))
+	handle(http.MethodGet, "/api/v1/snippets/:id", apiChain.ThenFunc(app.anonymousWithoutScope(models.ScopeSnippetsRead, app.apiSnippetGet)))
+	handle(http.MethodPost, "/api/v1/snippets", protectedAPIChain.ThenFunc(app.requireScope(models.ScopeSnippetsWrite, app.apiSnippetCreate)))
+	handle(http.MethodPut, "/api/v1/snippets/:id", protectedAPIChain.ThenFunc(app.requireScope(models.ScopeSnippetsWrite, app.apiSnippetUpdate)))
+	handle(http.MethodDelete, "/api/v1/snippets/:id", protectedAPIChain.ThenFunc(app.requireScope(models.ScopeSnippetsWrite, app.apiSnippetDelete)))
+	handle(http.MethodGet, "/api/v1/account", protectedAPIChain.ThenFunc(app.requireScope(models.ScopeAccountRead, app.apiAccountView)))
 
 	// Create a middlware chain containing the standard middleware
-	// which are to be used for every request our application receives.
-	standardChain := alice.New(app.recoverPanic, app.logRequests, secureHeaders)
+	// which are to be used for every request our application receives. Panics
+	// are recovered within logRequests, so that they show up in the access log.
+	standardChain := alice.New(assignRequestID, app.logRequests, app.recoverPanic, secureHeaders)
 
 	return standardChain.Then(router)
 }
+
+// adminRoutes returns the handler of the admin listener, which serves the
+// Prometheus metrics and the health checks over plain HTTP, for the monitoring
+// and the container healthcheck.
+func (app *application) adminRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", app.metrics.handler())
+	mux.HandleFunc("/healthz", app.healthz)
+	mux.HandleFunc("/readyz", app.readyz)
+
+	return mux
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// serve runs srv until ctx is cancelled, and then shuts it down gracefully: the
+// listener is closed straight away, but in-flight requests are given up to
+// timeout to complete. Once they have, serve waits for the background goroutines
+// of the application to finish as well.
+func (app *application) serve(ctx context.Context, srv *http.Server, certFile, keyFile string, timeout time.Duration) error {
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		app.logger.Info("shutting down web server", "timeout", timeout)
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		shutdownErr <- srv.Shutdown(shutdownCtx)
+	}()
+
+	// ListenAndServeTLS returns http.ErrServerClosed as soon as Shutdown is
+	// called, without waiting for the in-flight requests.
+	if err := srv.ListenAndServeTLS(certFile, keyFile); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	if err := <-shutdownErr; err != nil {
+		return err
+	}
+
+	app.wg.Wait()
+	return nil
+}
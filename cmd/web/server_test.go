@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestServeGracefulShutdown(t *testing.T) {
+	app := newTestApplication(t)
+
+	// Borrow the self-signed certificate of a test server, rather than reading
+	// the certificate files from disk.
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NilError(t, err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	requestStarted := make(chan struct{})
+	releaseRequest := make(chan struct{})
+	srv := &http.Server{
+		Addr:      addr,
+		ErrorLog:  slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
+		TLSConfig: &tls.Config{Certificates: certServer.TLS.Certificates},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(requestStarted)
+			<-releaseRequest
+			w.Write([]byte("OK"))
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	backgroundDone := false
+	app.wg.Add(1)
+	go func() {
+		defer app.wg.Done()
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		backgroundDone = true
+	}()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- app.serve(ctx, srv, "", "", time.Second)
+	}()
+
+	client := certServer.Client()
+	responses := make(chan string, 1)
+	go func() {
+		var rs *http.Response
+		var err error
+		// Retry until the server is listening.
+		for i := 0; i < 50; i++ {
+			rs, err = client.Get("https://" + addr)
+			if err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			responses <- err.Error()
+			return
+		}
+		defer rs.Body.Close()
+		body, _ := io.ReadAll(rs.Body)
+		responses <- string(body)
+	}()
+
+	// Stop the server while a request is in flight: it must still complete.
+	<-requestStarted
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	close(releaseRequest)
+
+	assert.Equal(t, <-responses, "OK")
+	assert.NilError(t, <-served)
+	assert.Equal(t, backgroundDone, true)
+}
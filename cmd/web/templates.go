@@ -1,11 +1,21 @@
 package main
 
 import (
+	"fmt"
 	"html/template"
 	"io/fs"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/vladComan0/go-snippets/internal/highlight"
+	"github.com/vladComan0/go-snippets/internal/markdown"
 	"github.com/vladComan0/go-snippets/internal/models"
 	"github.com/vladComan0/go-snippets/ui"
 )
@@ -17,8 +27,105 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+// timeLeft roughly describes how long is left until t, e.g. "3 days".
+func timeLeft(t time.Time) string {
+	d := time.Until(t)
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute")
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour")
+	default:
+		return plural(int(d/(24*time.Hour)), "day")
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// termsRX compiles a case-insensitive pattern matching any of the words in a
+// search query. It returns nil if the query doesn't contain any words.
+func termsRX(query string) *regexp.Regexp {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	for i, term := range terms {
+		terms[i] = regexp.QuoteMeta(term)
+	}
+
+	return regexp.MustCompile("(?i)" + strings.Join(terms, "|"))
+}
+
+// markTerms HTML-escapes text and wraps every occurrence of the words in the
+// search query in a <mark> element.
+func markTerms(text, query string) template.HTML {
+	rx := termsRX(query)
+	if rx == nil {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range rx.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
+
+// excerpt returns at most n characters of text, centred around the first
+// occurrence of any of the words in the search query.
+func excerpt(text, query string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	start := 0
+	if rx := termsRX(query); rx != nil {
+		if loc := rx.FindStringIndex(text); loc != nil {
+			start = max(0, utf8.RuneCountInString(text[:loc[0]])-n/4)
+		}
+	}
+	end := min(len(runes), start+n)
+	start = max(0, end-n)
+
+	result := string(runes[start:end])
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+
+	return result
+}
+
 var functions = template.FuncMap{
-	"humanDate": humanDate,
+	"humanDate":  humanDate,
+	"timeLeft":   timeLeft,
+	"markTerms":  markTerms,
+	"excerpt":    excerpt,
+	"contains":   slices.Contains[[]string],
+	"highlight":  highlight.Code,
+	"markdown":   markdown.Render,
+	"languages":  func() []highlight.Language { return highlight.Languages },
+	"language":   highlight.Label,
+	"expiries":   func() []expiryOption { return expiryOptions },
+	"extensions": extensionOptions,
 }
 
 func newTemplateCache() (map[string]*template.Template, error) {
@@ -33,7 +140,7 @@ func newTemplateCache() (map[string]*template.Template, error) {
 
 		patterns := []string{
 			"html/base.tmpl.html",
-			"html/partials/nav.tmpl.html",
+			"html/partials/*.tmpl.html",
 			page,
 		}
 
@@ -49,12 +156,95 @@ func newTemplateCache() (map[string]*template.Template, error) {
 }
 
 type templateData struct {
-	CurrentYear     int
-	Snippet         *models.Snippet
-	Snippets        []*models.Snippet
-	Form            any
-	Flash           string
-	IsAuthenticated bool
-	CSRFToken       string
-	User            *models.User
+	CurrentYear         int
+	Snippet             *models.Snippet
+	Snippets            []*models.Snippet
+	Form                any
+	Flash               string
+	IsAuthenticated     bool
+	AuthenticatedUserID int
+	CSRFToken           string
+	User                *models.User
+	Pagination          *pagination
+	Tokens              []*models.Token
+	NewToken            string
+	Revisions           []*models.Revision
+	Diff                *revisionDiff
+	Tag                 string
+}
+
+// pagination wraps the metadata of a paginated listing together with the query
+// string of the current request, so page links keep any other parameters intact.
+type pagination struct {
+	models.Metadata
+	query url.Values
+}
+
+func newPagination(metadata models.Metadata, query url.Values) *pagination {
+	return &pagination{
+		Metadata: metadata,
+		query:    query,
+	}
+}
+
+// URL returns the relative URL of the given page.
+func (p *pagination) URL(page int) string {
+	query := url.Values{}
+	for key, values := range p.query {
+		query[key] = values
+	}
+	query.Set("page", strconv.Itoa(page))
+
+	return "?" + query.Encode()
+}
+
+// revisionDiff holds the changes between two revisions of a snippet.
+type revisionDiff struct {
+	From    *models.Revision
+	To      *models.Revision
+	Unified string
+}
+
+// newRevisionDiff compares the files of two revisions. Files are matched by name:
+// the files of the newer revision come first, in order, followed by the ones it
+// removed.
+func newRevisionDiff(from, to *models.Revision) (*revisionDiff, error) {
+	names := []string{}
+	for _, file := range to.Files {
+		names = append(names, file.Name)
+	}
+	for _, file := range from.Files {
+		if to.File(file.Name) == nil {
+			names = append(names, file.Name)
+		}
+	}
+
+	var unified strings.Builder
+	for _, name := range names {
+		diff := difflib.UnifiedDiff{
+			FromFile: "/dev/null",
+			ToFile:   "/dev/null",
+			Context:  3,
+		}
+		if file := from.File(name); file != nil {
+			diff.A = difflib.SplitLines(file.Content)
+			diff.FromFile = "a/" + name
+		}
+		if file := to.File(name); file != nil {
+			diff.B = difflib.SplitLines(file.Content)
+			diff.ToFile = "b/" + name
+		}
+
+		text, err := difflib.GetUnifiedDiffString(diff)
+		if err != nil {
+			return nil, err
+		}
+		unified.WriteString(text)
+	}
+
+	return &revisionDiff{
+		From:    from,
+		To:      to,
+		Unified: unified.String(),
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/vladComan0/go-snippets/internal/assert"
+	"github.com/vladComan0/go-snippets/internal/models"
 )
 
 func TestHumanDate(t *testing.T) {
@@ -37,3 +38,162 @@ func TestHumanDate(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeLeft(t *testing.T) {
+	tests := []struct {
+		name     string
+		left     time.Duration
+		expected string
+	}{
+		{
+			name:     "Seconds",
+			left:     30 * time.Second,
+			expected: "less than a minute",
+		},
+		{
+			name:     "Minutes",
+			left:     10*time.Minute + 30*time.Second,
+			expected: "10 minutes",
+		},
+		{
+			name:     "One hour",
+			left:     time.Hour + 30*time.Second,
+			expected: "1 hour",
+		},
+		{
+			name:     "Days",
+			left:     3*24*time.Hour + 30*time.Second,
+			expected: "3 days",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual := timeLeft(time.Now().Add(subtest.left))
+			assert.Equal(t, actual, subtest.expected)
+		})
+	}
+}
+
+func TestMarkTerms(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "Single term",
+			text:     "An old silent pond",
+			query:    "pond",
+			expected: "An old silent <mark>pond</mark>",
+		},
+		{
+			name:     "Case insensitive",
+			text:     "An old silent pond",
+			query:    "OLD Pond",
+			expected: "An <mark>old</mark> silent <mark>pond</mark>",
+		},
+		{
+			name:     "Escapes HTML",
+			text:     "<script>alert('pond')</script>",
+			query:    "pond",
+			expected: "&lt;script&gt;alert(&#39;<mark>pond</mark>&#39;)&lt;/script&gt;",
+		},
+		{
+			name:     "Regexp metacharacters",
+			text:     "a+b",
+			query:    "a+b",
+			expected: "<mark>a+b</mark>",
+		},
+		{
+			name:     "Empty query",
+			text:     "An old <silent> pond",
+			query:    " ",
+			expected: "An old &lt;silent&gt; pond",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual := markTerms(subtest.text, subtest.query)
+			assert.Equal(t, string(actual), subtest.expected)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		query    string
+		n        int
+		expected string
+	}{
+		{
+			name:     "Short text",
+			text:     "An old silent pond",
+			query:    "pond",
+			n:        100,
+			expected: "An old silent pond",
+		},
+		{
+			name:     "Match at the start",
+			text:     "An old silent pond",
+			query:    "an",
+			n:        6,
+			expected: "An old…",
+		},
+		{
+			name:     "Match in the middle",
+			text:     "An old silent pond, a frog jumps into the pond",
+			query:    "frog",
+			n:        8,
+			expected: "…a frog j…",
+		},
+		{
+			name:     "Match at the end",
+			text:     "An old silent pond",
+			query:    "pond",
+			n:        6,
+			expected: "…t pond",
+		},
+		{
+			name:     "Multi-byte characters",
+			text:     "古池や蛙飛び込む水の音",
+			query:    "水",
+			n:        4,
+			expected: "…む水の音",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual := excerpt(subtest.text, subtest.query, subtest.n)
+			assert.Equal(t, actual, subtest.expected)
+		})
+	}
+}
+
+func TestNewRevisionDiff(t *testing.T) {
+	from := &models.Revision{Number: 1, Files: []*models.File{
+		{Name: "main.go", Content: "first line\nsecond line"},
+		{Name: "old.txt", Content: "removed"},
+	}}
+	to := &models.Revision{Number: 2, Files: []*models.File{
+		{Name: "main.go", Content: "first line\nchanged line"},
+		{Name: "new.txt", Content: "added"},
+	}}
+
+	diff, err := newRevisionDiff(from, to)
+	assert.NilError(t, err)
+
+	want := "--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n first line\n-second line\n+changed line\n" +
+		"--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+added\n" +
+		"--- a/old.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-removed\n"
+	assert.Equal(t, diff.Unified, want)
+
+	diff, err = newRevisionDiff(from, from)
+	assert.NilError(t, err)
+	assert.Equal(t, diff.Unified, "")
+}
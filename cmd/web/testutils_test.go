@@ -2,14 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"html"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
 	"net/url"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,16 +39,27 @@ func newTestApplication(t *testing.T) *application {
 	sessionManager.Cookie.Secure = true
 
 	return &application{
-		errorLog:       log.New(io.Discard, "", 0),
-		infoLog:        log.New(io.Discard, "", 0),
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:             &mockDB{},
 		snippets:       &mocks.SnippetModel{},
 		users:          &mocks.UserModel{},
+		tokens:         &mocks.TokenModel{},
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
+		metrics:        newMetrics(),
 	}
 }
 
+// mockDB is a database which can be pinged, unless it has been given an error.
+type mockDB struct {
+	err error
+}
+
+func (db *mockDB) PingContext(ctx context.Context) error {
+	return db.err
+}
+
 type testServer struct {
 	*httptest.Server
 }
@@ -107,6 +120,68 @@ func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (i
 	return response.StatusCode, response.Header, string(responseBody)
 }
 
+// sendJSON sends a request with the given JSON body (which may be empty) to the
+// test server.
+func (ts *testServer) sendJSON(t *testing.T, method, urlPath, body string) (int, http.Header, string) {
+	return ts.do(t, newJSONRequest(t, method, ts.URL+urlPath, body))
+}
+
+// sendJSONWithToken is like sendJSON, but authenticates the request with the
+// given API token instead of the client's cookies.
+func (ts *testServer) sendJSONWithToken(t *testing.T, method, urlPath, token, body string) (int, http.Header, string) {
+	request := newJSONRequest(t, method, ts.URL+urlPath, body)
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	return ts.do(t, request)
+}
+
+func newJSONRequest(t *testing.T, method, url, body string) *http.Request {
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	return request
+}
+
+func (ts *testServer) do(t *testing.T, request *http.Request) (int, http.Header, string) {
+	response, err := ts.Client().Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response.StatusCode, response.Header, string(responseBody)
+}
+
+// login authenticates the test server client as the mock user "alice@example.com"
+// and returns a CSRF token which can be used for subsequent POST requests.
+func (ts *testServer) login(t *testing.T) string {
+	_, _, body := ts.get(t, "/user/login")
+	csrfToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("email", "alice@example.com")
+	form.Add("password", "pa$$word")
+	form.Add("csrf_token", csrfToken)
+
+	code, _, _ := ts.postForm(t, "/user/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("login failed with status code %d", code)
+	}
+
+	_, _, body = ts.get(t, "/snippet/create")
+	return extractCSRFToken(t, body)
+}
+
 func extractCSRFToken(t *testing.T, body string) string {
 	matches := csrfTokenRX.FindStringSubmatch(body)
 	if len(matches) < 2 {
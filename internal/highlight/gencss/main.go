@@ -0,0 +1,25 @@
+// Command gencss writes the style sheet used by syntax highlighted snippets.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/vladComan0/go-snippets/internal/highlight"
+)
+
+func main() {
+	output := flag.String("o", "highlight.css", "Path of the generated style sheet.")
+	flag.Parse()
+
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := highlight.WriteCSS(f); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,115 @@
+// Package highlight renders snippet content as syntax highlighted HTML.
+//
+// The generated markup only uses CSS classes (never inline styles), so that it
+// is allowed by the application's Content-Security-Policy. The matching style
+// sheet lives in ui/static/css/highlight.css and is generated with go generate.
+package highlight
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// PlainText is the language of snippets which shouldn't be highlighted.
+const PlainText = "plaintext"
+
+// Language is a language snippets can be tagged with. Name is the value stored
+// in the database and the name of the chroma lexer used for highlighting, while
+// Extension is the file extension used when a snippet is downloaded.
+type Language struct {
+	Name      string
+	Label     string
+	Extension string
+}
+
+// Languages lists every language a snippet can be tagged with.
+var Languages = []Language{
+	{Name: PlainText, Label: "Plain text", Extension: ".txt"},
+	{Name: "bash", Label: "Bash", Extension: ".sh"},
+	{Name: "c", Label: "C", Extension: ".c"},
+	{Name: "cpp", Label: "C++", Extension: ".cpp"},
+	{Name: "css", Label: "CSS", Extension: ".css"},
+	{Name: "docker", Label: "Dockerfile", Extension: ".dockerfile"},
+	{Name: "go", Label: "Go", Extension: ".go"},
+	{Name: "html", Label: "HTML", Extension: ".html"},
+	{Name: "java", Label: "Java", Extension: ".java"},
+	{Name: "javascript", Label: "JavaScript", Extension: ".js"},
+	{Name: "json", Label: "JSON", Extension: ".json"},
+	{Name: "makefile", Label: "Makefile", Extension: ".mk"},
+	{Name: "markdown", Label: "Markdown", Extension: ".md"},
+	{Name: "python", Label: "Python", Extension: ".py"},
+	{Name: "rust", Label: "Rust", Extension: ".rs"},
+	{Name: "sql", Label: "SQL", Extension: ".sql"},
+	{Name: "typescript", Label: "TypeScript", Extension: ".ts"},
+	{Name: "yaml", Label: "YAML", Extension: ".yaml"},
+}
+
+// styleName is the chroma style the highlight.css style sheet is generated from.
+const styleName = "github"
+
+var formatter = html.New(html.WithClasses(true), html.WithLineNumbers(true), html.TabWidth(4))
+
+// Names returns the names of every supported language.
+func Names() []string {
+	names := make([]string, len(Languages))
+	for i, language := range Languages {
+		names[i] = language.Name
+	}
+	return names
+}
+
+// Label returns the human readable label of a language.
+func Label(name string) string {
+	for _, language := range Languages {
+		if language.Name == name {
+			return language.Label
+		}
+	}
+	return name
+}
+
+// Extension returns the file extension of a language, falling back to the one
+// of plain text for unknown languages.
+func Extension(name string) string {
+	for _, language := range Languages {
+		if language.Name == name {
+			return language.Extension
+		}
+	}
+	return ".txt"
+}
+
+// Code renders source as highlighted HTML, wrapped in a <pre> element. Unknown
+// languages are rendered without highlighting.
+func Code(source, language string) (template.HTML, error) {
+	lexer := lexers.Get(language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := formatter.Format(&buf, styles.Get(styleName), iterator); err != nil {
+		return "", err
+	}
+
+	// The chroma formatter HTML-escapes every token it writes, so its output is
+	// safe to embed in the page as is.
+	return template.HTML(buf.String()), nil
+}
+
+// WriteCSS writes the style sheet matching the classes used by Code.
+func WriteCSS(w io.Writer) error {
+	return formatter.WriteCSS(w, styles.Get(styleName))
+}
@@ -0,0 +1,57 @@
+package highlight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		language string
+		want     string
+	}{
+		{
+			name:     "Go",
+			source:   "package main",
+			language: "go",
+			want:     `<span class="kn">package</span>`,
+		},
+		{
+			name:     "Escapes HTML",
+			source:   "<script>alert('pond')</script>",
+			language: PlainText,
+			want:     "&lt;script&gt;",
+		},
+		{
+			name:     "Unknown language",
+			source:   "An old silent pond",
+			language: "haiku",
+			want:     "An old silent pond",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual, err := Code(subtest.source, subtest.language)
+
+			assert.NilError(t, err)
+			assert.StringContains(t, string(actual), subtest.want)
+			// Inline styles would be blocked by the Content-Security-Policy.
+			assert.Equal(t, strings.Contains(string(actual), "style="), false)
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, Label("go"), "Go")
+	assert.Equal(t, Label("haiku"), "haiku")
+}
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, Extension("go"), ".go")
+	assert.Equal(t, Extension("haiku"), ".txt")
+}
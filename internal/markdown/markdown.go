@@ -0,0 +1,106 @@
+// Package markdown renders prose snippets written in Markdown as HTML.
+//
+// Raw HTML in the source is never passed through, links are marked with
+// rel="nofollow noopener" and fenced code blocks are highlighted with the
+// highlight package, so the output only uses markup and CSS classes which are
+// allowed by the application's Content-Security-Policy.
+package markdown
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/vladComan0/go-snippets/internal/highlight"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Language is the language files rendered as Markdown are tagged with.
+const Language = "markdown"
+
+// linkRel is the rel attribute added to every link, since the linked pages
+// are chosen by the snippet's author rather than by us.
+var linkRel = []byte("nofollow noopener")
+
+var md = goldmark.New(
+	goldmark.WithExtensions(
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.TaskList,
+		// Alignments are rendered as attributes, as inline styles would be
+		// blocked by the Content-Security-Policy.
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+	),
+	goldmark.WithParserOptions(
+		parser.WithASTTransformers(util.Prioritized(linkTransformer{}, 100)),
+	),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(codeBlockRenderer{}, 100)),
+	),
+)
+
+// Render renders source as HTML. Raw HTML blocks and inline HTML are omitted
+// and links with dangerous schemes such as javascript: are dropped.
+func Render(source string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	// goldmark escapes all text and attributes it writes, and we never enable
+	// its unsafe mode, so the output is safe to embed in the page as is.
+	return template.HTML(buf.String()), nil
+}
+
+// linkTransformer adds the rel attribute to links and autolinks.
+type linkTransformer struct{}
+
+func (linkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch n.Kind() {
+		case ast.KindLink, ast.KindAutoLink:
+			n.SetAttributeString("rel", linkRel)
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// codeBlockRenderer renders fenced code blocks with highlight.Code, using the
+// block's info string as the language.
+type codeBlockRenderer struct{}
+
+func (codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, renderFencedCodeBlock)
+}
+
+func renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	n := node.(*ast.FencedCodeBlock)
+
+	var code bytes.Buffer
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	html, err := highlight.Code(code.String(), string(n.Language(source)))
+	if err != nil {
+		return ast.WalkStop, err
+	}
+
+	_, _ = w.WriteString(string(html))
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,75 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		reject string
+	}{
+		{
+			name:   "Heading",
+			source: "# An old silent pond",
+			want:   "<h1>An old silent pond</h1>",
+		},
+		{
+			name:   "Raw HTML",
+			source: "<script>alert('pond')</script>",
+			want:   "<!-- raw HTML omitted -->",
+			reject: "<script>",
+		},
+		{
+			name:   "Inline HTML",
+			source: "An old <img src=x onerror=alert(1)> pond",
+			reject: "<img",
+		},
+		{
+			name:   "Link",
+			source: "[Pond](https://example.com)",
+			want:   `<a href="https://example.com" rel="nofollow noopener">Pond</a>`,
+		},
+		{
+			name:   "Autolink",
+			source: "See https://example.com",
+			want:   `rel="nofollow noopener"`,
+		},
+		{
+			name:   "Dangerous link",
+			source: "[Pond](javascript:alert(1))",
+			reject: "javascript:",
+		},
+		{
+			name:   "Fenced code block",
+			source: "```go\npackage main\n```",
+			want:   `<span class="kn">package</span>`,
+		},
+		{
+			name:   "Table alignment",
+			source: "| Season |\n|:------:|\n| Autumn |",
+			want:   `align="center"`,
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			actual, err := Render(subtest.source)
+
+			assert.NilError(t, err)
+			if subtest.want != "" {
+				assert.StringContains(t, string(actual), subtest.want)
+			}
+			if subtest.reject != "" {
+				assert.Equal(t, strings.Contains(string(actual), subtest.reject), false)
+			}
+			// Inline styles would be blocked by the Content-Security-Policy.
+			assert.Equal(t, strings.Contains(string(actual), "style="), false)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Names of the supported database backends.
+const (
+	DriverMySQL    = "mysql"
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+var Drivers = []string{DriverMySQL, DriverSQLite, DriverPostgres}
+
+// Dialect adapts the queries of the models to one of the supported database
+// backends. The models stick to the SQL understood by all of them, and leave
+// the rest to the dialect. Dialects are only implemented in this package.
+type Dialect interface {
+	// Name is the name of the backend, one of Drivers.
+	Name() string
+	// DriverName is the name the database/sql driver of the backend is
+	// registered with.
+	DriverName() string
+
+	// rebind rewrites the ? placeholders of a query into those of the backend.
+	rebind(query string) string
+	// insertID runs an INSERT statement into a table with an auto-incremented
+	// id column, and returns the ID of the new row.
+	insertID(ctx context.Context, c conn, query string, args ...any) (int, error)
+	// insertIgnore returns an INSERT statement which skips the rows that would
+	// violate a unique constraint.
+	insertIgnore(table, columns, values string) string
+	// forUpdate is appended to the SELECT statements which lock the rows they
+	// read until the end of their transaction.
+	forUpdate() string
+	// searchMatches returns a query selecting the snippet_id and score of the
+	// snippet titles and files matching terms, together with its arguments.
+	searchMatches(terms string) (string, []any)
+	// isUniqueViolation reports whether err is caused by a unique constraint.
+	isUniqueViolation(err error) bool
+	// setForeignKeys turns the foreign key constraints of a connection on or
+	// off, for the migrations which rebuild tables. It does nothing on the
+	// backends which alter their tables in place.
+	setForeignKeys(ctx context.Context, c *sql.Conn, on bool) error
+	// checkForeignKeys returns an error if the rows written while the foreign
+	// keys were off violate them.
+	checkForeignKeys(ctx context.Context, c conn) error
+	// lockMigrations waits for the lock of the migrations of the database, and
+	// takes it for the connection until unlockMigrations releases it.
+	lockMigrations(ctx context.Context, c *sql.Conn) error
+	unlockMigrations(ctx context.Context, c *sql.Conn) error
+}
+
+// DialectFor returns the dialect of the named backend.
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case DriverMySQL:
+		return mysqlDialect{}, nil
+	case DriverSQLite:
+		return sqliteDialect{}, nil
+	case DriverPostgres:
+		return postgresDialect{}, nil
+	default:
+		return nil, fmt.Errorf("models: unknown database driver %q, expected one of %s", driver, strings.Join(Drivers, ", "))
+	}
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn runs the queries of the models on a database or transaction, rewritten
+// for its dialect.
+type conn struct {
+	q       querier
+	dialect Dialect
+}
+
+func (c conn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return c.q.ExecContext(ctx, c.dialect.rebind(query), args...)
+}
+
+func (c conn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return c.q.QueryContext(ctx, c.dialect.rebind(query), args...)
+}
+
+func (c conn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return c.q.QueryRowContext(ctx, c.dialect.rebind(query), args...)
+}
+
+// insertReturningID implements Dialect.insertID for the backends which support
+// INSERT ... RETURNING, but not sql.Result.LastInsertId.
+func insertReturningID(ctx context.Context, c conn, query string, args ...any) (int, error) {
+	var id int
+	err := c.QueryRowContext(ctx, query+` RETURNING id`, args...).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ERR_DUP_ENTRY is the MySQL error number for duplicate entries.
+const ERR_DUP_ENTRY = 1062
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string       { return DriverMySQL }
+func (mysqlDialect) DriverName() string { return "mysql" }
+
+func (mysqlDialect) rebind(query string) string { return query }
+
+func (mysqlDialect) insertID(ctx context.Context, c conn, query string, args ...any) (int, error) {
+	result, err := c.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (mysqlDialect) insertIgnore(table, columns, values string) string {
+	return `INSERT IGNORE INTO ` + table + `(` + columns + `) VALUES ` + values
+}
+
+func (mysqlDialect) forUpdate() string { return ` FOR UPDATE` }
+
+// searchMatches uses the FULLTEXT indexes of the titles and files.
+func (mysqlDialect) searchMatches(terms string) (string, []any) {
+	query := `SELECT id AS snippet_id, MATCH(title) AGAINST(? IN NATURAL LANGUAGE MODE) AS score
+	FROM snippets WHERE MATCH(title) AGAINST(? IN NATURAL LANGUAGE MODE)
+	UNION ALL
+	SELECT snippet_id, MATCH(filename, content) AGAINST(? IN NATURAL LANGUAGE MODE)
+	FROM snippet_files WHERE MATCH(filename, content) AGAINST(? IN NATURAL LANGUAGE MODE)`
+	return query, []any{terms, terms, terms, terms}
+}
+
+func (mysqlDialect) isUniqueViolation(err error) bool {
+	var mySQLError *mysql.MySQLError
+	return errors.As(err, &mySQLError) && mySQLError.Number == ERR_DUP_ENTRY
+}
+
+// setForeignKeys does nothing, since the migrations alter the tables in place.
+func (mysqlDialect) setForeignKeys(ctx context.Context, c *sql.Conn, on bool) error { return nil }
+
+func (mysqlDialect) checkForeignKeys(ctx context.Context, c conn) error { return nil }
+
+// MYSQL_MIGRATIONS_LOCK is the name of the MySQL user lock taken by the migrations.
+const MYSQL_MIGRATIONS_LOCK = "snippetbox_migrations"
+
+// lockMigrations takes a user lock, which is released when the connection
+// closes, should the process die while holding it.
+func (mysqlDialect) lockMigrations(ctx context.Context, c *sql.Conn) error {
+	var locked sql.NullInt64
+	if err := c.QueryRowContext(ctx, `SELECT GET_LOCK(?, -1)`, MYSQL_MIGRATIONS_LOCK).Scan(&locked); err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return errors.New("models: couldn't take the lock of the migrations")
+	}
+	return nil
+}
+
+func (mysqlDialect) unlockMigrations(ctx context.Context, c *sql.Conn) error {
+	_, err := c.ExecContext(ctx, `DO RELEASE_LOCK(?)`, MYSQL_MIGRATIONS_LOCK)
+	return err
+}
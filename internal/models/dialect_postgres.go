@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+)
+
+// PG_UNIQUE_VIOLATION is the PostgreSQL error code for duplicate entries.
+const PG_UNIQUE_VIOLATION = "23505"
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string       { return DriverPostgres }
+func (postgresDialect) DriverName() string { return "pgx" }
+
+// rebind numbers the placeholders: PostgreSQL uses $1, $2... instead of ?. The
+// queries of the models never contain a literal question mark.
+func (postgresDialect) rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+func (postgresDialect) insertID(ctx context.Context, c conn, query string, args ...any) (int, error) {
+	return insertReturningID(ctx, c, query, args...)
+}
+
+func (postgresDialect) insertIgnore(table, columns, values string) string {
+	return `INSERT INTO ` + table + `(` + columns + `) VALUES ` + values + ` ON CONFLICT DO NOTHING`
+}
+
+func (postgresDialect) forUpdate() string { return ` FOR UPDATE` }
+
+// searchMatches uses the full-text search of PostgreSQL, with the "simple"
+// configuration so that, like MySQL, words aren't stemmed.
+func (postgresDialect) searchMatches(terms string) (string, []any) {
+	query := `SELECT id AS snippet_id, ts_rank(to_tsvector('simple', title), plainto_tsquery('simple', ?)) AS score
+	FROM snippets WHERE to_tsvector('simple', title) @@ plainto_tsquery('simple', ?)
+	UNION ALL
+	SELECT snippet_id, ts_rank(to_tsvector('simple', filename || ' ' || content), plainto_tsquery('simple', ?))
+	FROM snippet_files WHERE to_tsvector('simple', filename || ' ' || content) @@ plainto_tsquery('simple', ?)`
+	return query, []any{terms, terms, terms, terms}
+}
+
+func (postgresDialect) isUniqueViolation(err error) bool {
+	var pgError *pgconn.PgError
+	return errors.As(err, &pgError) && pgError.Code == PG_UNIQUE_VIOLATION
+}
+
+// setForeignKeys does nothing, since the migrations alter the tables in place.
+func (postgresDialect) setForeignKeys(ctx context.Context, c *sql.Conn, on bool) error { return nil }
+
+func (postgresDialect) checkForeignKeys(ctx context.Context, c conn) error { return nil }
+
+// PG_MIGRATIONS_LOCK is the key of the PostgreSQL advisory lock taken by the
+// migrations.
+const PG_MIGRATIONS_LOCK = 7_301_485_126
+
+// lockMigrations takes a session advisory lock, which is released when the
+// connection closes, should the process die while holding it.
+func (postgresDialect) lockMigrations(ctx context.Context, c *sql.Conn) error {
+	_, err := c.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, PG_MIGRATIONS_LOCK)
+	return err
+}
+
+func (postgresDialect) unlockMigrations(ctx context.Context, c *sql.Conn) error {
+	_, err := c.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, PG_MIGRATIONS_LOCK)
+	return err
+}
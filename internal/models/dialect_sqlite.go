@@ -0,0 +1,95 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteDialect is meant for local development and hermetic tests. The data
+// source name must enable foreign keys, which the schema relies on to delete
+// the files, revisions and tags of the snippets, and the "sqlite" time format,
+// so that dates compare in chronological order:
+//
+//	file:snippetbox.db?_pragma=foreign_keys(1)&_time_format=sqlite
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string       { return DriverSQLite }
+func (sqliteDialect) DriverName() string { return "sqlite" }
+
+func (sqliteDialect) rebind(query string) string { return query }
+
+func (sqliteDialect) insertID(ctx context.Context, c conn, query string, args ...any) (int, error) {
+	return insertReturningID(ctx, c, query, args...)
+}
+
+func (sqliteDialect) insertIgnore(table, columns, values string) string {
+	return `INSERT INTO ` + table + `(` + columns + `) VALUES ` + values + ` ON CONFLICT DO NOTHING`
+}
+
+// forUpdate is empty, since SQLite locks the whole database as soon as a
+// transaction writes to it, and the models write before they read.
+func (sqliteDialect) forUpdate() string { return `` }
+
+// searchMatches looks for the terms as a whole in the titles and files, since
+// SQLite has no full-text search without the FTS5 virtual tables. Every match
+// scores one point.
+func (sqliteDialect) searchMatches(terms string) (string, []any) {
+	pattern := "%" + likeEscaper.Replace(terms) + "%"
+	query := `SELECT id AS snippet_id, 1 AS score FROM snippets WHERE title LIKE ? ESCAPE '\'
+	UNION ALL
+	SELECT snippet_id, 1 FROM snippet_files WHERE filename LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\'`
+	return query, []any{pattern, pattern, pattern}
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (sqliteDialect) isUniqueViolation(err error) bool {
+	var sqliteError *sqlite.Error
+	return errors.As(err, &sqliteError) &&
+		(sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteError.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+// setForeignKeys sets the foreign_keys pragma. SQLite can't change most
+// constraints in place, so the migrations rebuild the tables they alter, and
+// dropping the old snippets table with the foreign keys on would delete the
+// rows of the tables which refer to it. The pragma is ignored in transactions.
+func (sqliteDialect) setForeignKeys(ctx context.Context, c *sql.Conn, on bool) error {
+	pragma := `PRAGMA foreign_keys = OFF`
+	if on {
+		pragma = `PRAGMA foreign_keys = ON`
+	}
+	_, err := c.ExecContext(ctx, pragma)
+	return err
+}
+
+func (sqliteDialect) checkForeignKeys(ctx context.Context, c conn) error {
+	rows, err := c.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		var table string
+		var rowID, parent, fkID any
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		return fmt.Errorf("models: row %v of the %s table violates a foreign key to the %v table", rowID, table, parent)
+	}
+	return rows.Err()
+}
+
+// lockMigrations does nothing: SQLite only lets one transaction write at a
+// time, so the transaction of every migration is the lock, under which it checks
+// that no other process has run it meanwhile.
+func (sqliteDialect) lockMigrations(ctx context.Context, c *sql.Conn) error { return nil }
+
+func (sqliteDialect) unlockMigrations(ctx context.Context, c *sql.Conn) error { return nil }
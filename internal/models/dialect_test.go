@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestDialectFor(t *testing.T) {
+	for _, driver := range Drivers {
+		dialect, err := DialectFor(driver)
+		assert.NilError(t, err)
+		assert.Equal(t, dialect.Name(), driver)
+	}
+
+	_, err := DialectFor("oracle")
+	assert.StringContains(t, err.Error(), `unknown database driver "oracle"`)
+}
+
+func TestPostgresRebind(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "No placeholders",
+			query: "SELECT 1",
+			want:  "SELECT 1",
+		},
+		{
+			name:  "Placeholders",
+			query: "SELECT id FROM snippets WHERE id IN (?,?) LIMIT ? OFFSET ?",
+			want:  "SELECT id FROM snippets WHERE id IN ($1,$2) LIMIT $3 OFFSET $4",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			assert.Equal(t, postgresDialect{}.rebind(subtest.query), subtest.want)
+		})
+	}
+}
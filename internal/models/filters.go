@@ -0,0 +1,59 @@
+package models
+
+// Filters holds the pagination parameters accepted by the listing queries.
+type Filters struct {
+	Page     int
+	PageSize int
+}
+
+func (f Filters) limit() int {
+	return f.PageSize
+}
+
+func (f Filters) offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// Metadata describes where a page of results sits within the full result set.
+type Metadata struct {
+	CurrentPage  int `json:"current_page,omitempty"`
+	PageSize     int `json:"page_size,omitempty"`
+	FirstPage    int `json:"first_page,omitempty"`
+	LastPage     int `json:"last_page,omitempty"`
+	TotalRecords int `json:"total_records"`
+}
+
+// calculateMetadata returns the pagination metadata for a listing, given the
+// total number of records and the filters used to fetch the current page. An
+// empty Metadata value is returned when there are no records at all.
+func calculateMetadata(totalRecords, page, pageSize int) Metadata {
+	if totalRecords == 0 {
+		return Metadata{}
+	}
+
+	return Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     (totalRecords + pageSize - 1) / pageSize,
+		TotalRecords: totalRecords,
+	}
+}
+
+// HasPrevious reports whether there is a page before the current one.
+func (m Metadata) HasPrevious() bool {
+	return m.CurrentPage > m.FirstPage
+}
+
+// HasNext reports whether there is a page after the current one.
+func (m Metadata) HasNext() bool {
+	return m.CurrentPage < m.LastPage
+}
+
+func (m Metadata) PreviousPage() int {
+	return m.CurrentPage - 1
+}
+
+func (m Metadata) NextPage() int {
+	return m.CurrentPage + 1
+}
@@ -0,0 +1,345 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The migrations of every backend live in migrations/<backend>, as pairs of
+// <version>_<name>.up.sql and <version>_<name>.down.sql scripts. The backends
+// must have the same versions, so that a migration means the same on all of them.
+//
+//go:embed "migrations"
+var migrationFiles embed.FS
+
+// ErrUnknownMigration is returned when the database has a migration applied
+// which isn't embedded in the binary, which happens when an older binary is run
+// against a database migrated by a newer one.
+var ErrUnknownMigration = errors.New("models: the database has migrations unknown to this binary")
+
+// Migration is a versioned change to the database schema.
+type Migration struct {
+	Version int
+	Name    string
+	// Applied is when the migration was applied, or the zero time if it's
+	// still pending.
+	Applied time.Time
+	up      string
+	down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Migrator applies the embedded migrations of its dialect to a database, and
+// records them in the schema_migrations table. Every migration runs in its own
+// transaction, except on MySQL, where the DDL statements commit implicitly: a
+// failed MySQL migration has to be cleaned up by hand before it's run again.
+//
+// The migrations are run under a lock of the database, so that the replicas of
+// the application started together with the -migrate flag don't apply the same
+// migration twice: the others wait, and then find it applied.
+type Migrator struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+// errMigrationDone is returned by run when another process has applied or
+// reverted the migration first.
+var errMigrationDone = errors.New("models: migration already done")
+
+// Status returns all the migrations, in order, with the time they were applied
+// at.
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	var migrations []Migration
+	err := m.locked(ctx, func(c *sql.Conn) error {
+		var err error
+		migrations, err = m.status(ctx, c)
+		return err
+	})
+	return migrations, err
+}
+
+// status is Status on a connection holding the lock of the migrations.
+func (m *Migrator) status(ctx context.Context, c *sql.Conn) ([]Migration, error) {
+	migrations, err := loadMigrations(m.Dialect.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	db := conn{c, m.Dialect}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version, applied FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range migrations {
+		if at, ok := applied[migrations[i].Version]; ok {
+			migrations[i].Applied = at
+			delete(applied, migrations[i].Version)
+		}
+	}
+	if len(applied) > 0 {
+		return nil, ErrUnknownMigration
+	}
+
+	return migrations, nil
+}
+
+// Up applies all the pending migrations in order, and returns them. It stops at
+// the first one which fails.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.upTo(ctx, math.MaxInt)
+}
+
+// upTo is like Up, but leaves the migrations after the given version pending.
+func (m *Migrator) upTo(ctx context.Context, version int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(c *sql.Conn) error {
+		migrations, err := m.status(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if migration.Version > version {
+				break
+			}
+			if !migration.Applied.IsZero() {
+				continue
+			}
+			migration.Applied = time.Now().UTC()
+			err := m.run(ctx, c, migration.up, migration.Version, false,
+				`INSERT INTO schema_migrations (version, name, applied) VALUES (?, ?, ?)`,
+				migration.Version, migration.Name, migration.Applied)
+			if errors.Is(err, errMigrationDone) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("models: migration %s: %w", migration, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, most recent first, and
+// returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(c *sql.Conn) error {
+		migrations, err := m.status(ctx, c)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := migrations[i]
+			if migration.Applied.IsZero() {
+				continue
+			}
+			err := m.run(ctx, c, migration.down, migration.Version, true,
+				`DELETE FROM schema_migrations WHERE version = ?`, migration.Version)
+			if errors.Is(err, errMigrationDone) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("models: migration %s: %w", migration, err)
+			}
+			migration.Applied = time.Time{}
+			done = append(done, migration)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// locked runs f on a connection holding the lock of the migrations.
+func (m *Migrator) locked(ctx context.Context, f func(c *sql.Conn) error) (err error) {
+	c, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := m.Dialect.lockMigrations(ctx, c); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := m.Dialect.unlockMigrations(context.Background(), c); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return f(c)
+}
+
+// run executes the statements of a migration script, and then the statement
+// recording it, in a transaction. It first checks that the migration of the
+// given version is still applied, or still pending, and returns
+// errMigrationDone otherwise. The queries aren't given the usual queryTimeout,
+// since altering a large table can take a while.
+func (m *Migrator) run(ctx context.Context, c *sql.Conn, script string, version int, applied bool, record string, args ...any) (err error) {
+	// The foreign keys are turned off on the connection of the migration, which
+	// some backends need to rebuild the tables that other tables refer to, and
+	// checked before it commits.
+	if err := m.Dialect.setForeignKeys(ctx, c, false); err != nil {
+		return err
+	}
+	defer func() {
+		if fkErr := m.Dialect.setForeignKeys(context.Background(), c, true); err == nil {
+			err = fkErr
+		}
+	}()
+
+	sqlTx, err := c.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	tx := conn{sqlTx, m.Dialect}
+
+	var count int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if (count > 0) != applied {
+		return errMigrationDone
+	}
+
+	for _, statement := range splitStatements(script) {
+		if _, err := sqlTx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	if err := m.Dialect.checkForeignKeys(ctx, tx); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+// loadMigrations returns the embedded migrations of a backend, ordered by
+// version.
+func loadMigrations(backend string) ([]Migration, error) {
+	dir := path.Join("migrations", backend)
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		version, name, found := strings.Cut(base, "_")
+		n, err := strconv.Atoi(version)
+		if !ok || !found || err != nil || n <= 0 || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("models: invalid migration file name %s/%s", dir, entry.Name())
+		}
+
+		script, err := fs.ReadFile(migrationFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[n]
+		if !exists {
+			migration = &Migration{Version: n, Name: name}
+			byVersion[n] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("models: duplicate migration version %d in %s", n, dir)
+		}
+
+		if direction == "up" {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" || migration.down == "" {
+			return nil, fmt.Errorf("models: migration %s/%s needs both an up and a down script", dir, migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// splitStatements splits a migration script into its statements, which must
+// end with a semicolon at the end of a line. The drivers don't all run several
+// statements in a single Exec call. Comment lines are dropped.
+func splitStatements(script string) []string {
+	var statements []string
+	var statement strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		statement.WriteString(line)
+		statement.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(statement.String()))
+			statement.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
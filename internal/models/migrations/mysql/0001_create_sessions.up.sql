@@ -0,0 +1,10 @@
+-- The tables are only created if they don't exist, so that the databases set
+-- up by the former build/init.sql script are adopted by the migrations.
+
+CREATE TABLE IF NOT EXISTS `sessions` (
+  `token` char(43) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `data` blob NOT NULL,
+  `expiry` timestamp(6) NOT NULL,
+  PRIMARY KEY (`token`),
+  KEY `sessions_expiry_idx` (`expiry`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
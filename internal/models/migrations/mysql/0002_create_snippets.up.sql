@@ -1,17 +1,8 @@
-CREATE SCHEMA IF NOT EXISTS `snippetbox`;
-USE `snippetbox`;
+-- This is the schema of the former build/init.sql script. The tables are only
+-- created if they don't exist, so that the databases it set up are adopted by
+-- the migrations, and brought up to date by the following ones.
 
-GRANT SELECT, INSERT, UPDATE, DELETE ON `snippetbox`.* TO `snippet_user`@`%`;
-
-CREATE TABLE `sessions` (
-  `token` char(43) COLLATE utf8mb4_unicode_ci NOT NULL,
-  `data` blob NOT NULL,
-  `expiry` timestamp(6) NOT NULL,
-  PRIMARY KEY (`token`),
-  KEY `sessions_expiry_idx` (`expiry`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-
-CREATE TABLE `snippets` (
+CREATE TABLE IF NOT EXISTS `snippets` (
   `id` int NOT NULL AUTO_INCREMENT,
   `title` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
   `content` text COLLATE utf8mb4_unicode_ci NOT NULL,
@@ -19,9 +10,9 @@ CREATE TABLE `snippets` (
   `expires` datetime NOT NULL,
   PRIMARY KEY (`id`),
   KEY `idx_snippets_created` (`created`)
-) ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
-CREATE TABLE `users` (
+CREATE TABLE IF NOT EXISTS `users` (
   `id` int NOT NULL AUTO_INCREMENT,
   `name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
   `email` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL,
@@ -29,4 +20,4 @@ CREATE TABLE `users` (
   `created` datetime NOT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `user_uc_email` (`email`)
-) ENGINE=InnoDB AUTO_INCREMENT=7 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
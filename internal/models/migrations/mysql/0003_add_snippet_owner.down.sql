@@ -0,0 +1,5 @@
+ALTER TABLE `snippets` DROP FOREIGN KEY `fk_snippets_user_id`;
+
+ALTER TABLE `snippets` DROP KEY `idx_snippets_user_id`, DROP COLUMN `user_id`;
+
+DELETE FROM `users` WHERE `email` = 'anonymous@snippetbox.invalid';
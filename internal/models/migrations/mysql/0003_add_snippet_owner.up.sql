@@ -0,0 +1,16 @@
+-- The snippets created before their owner was recorded are given to an
+-- Anonymous account, which can't log in: its password hash is "!", which no
+-- password matches.
+INSERT INTO `users` (`name`, `email`, `hashed_password`, `created`)
+SELECT 'Anonymous', 'anonymous@snippetbox.invalid', '!', `first`.`created`
+FROM (SELECT MIN(`created`) AS `created` FROM `snippets`) AS `first`
+WHERE `first`.`created` IS NOT NULL;
+
+ALTER TABLE `snippets` ADD COLUMN `user_id` int DEFAULT NULL AFTER `id`;
+
+UPDATE `snippets` SET `user_id` = (SELECT `id` FROM `users` WHERE `email` = 'anonymous@snippetbox.invalid');
+
+ALTER TABLE `snippets`
+  MODIFY `user_id` int NOT NULL,
+  ADD KEY `idx_snippets_user_id` (`user_id`),
+  ADD CONSTRAINT `fk_snippets_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
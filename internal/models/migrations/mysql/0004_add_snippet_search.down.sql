@@ -0,0 +1 @@
+ALTER TABLE `snippets` DROP KEY `idx_snippets_fulltext`;
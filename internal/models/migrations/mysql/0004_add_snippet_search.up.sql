@@ -0,0 +1 @@
+ALTER TABLE `snippets` ADD FULLTEXT KEY `idx_snippets_fulltext` (`title`, `content`);
@@ -0,0 +1 @@
+ALTER TABLE `snippets` DROP KEY `snippets_uc_slug`, DROP COLUMN `slug`, DROP COLUMN `visibility`;
@@ -0,0 +1,11 @@
+ALTER TABLE `snippets`
+  ADD COLUMN `slug` char(16) COLLATE utf8mb4_bin DEFAULT NULL AFTER `id`,
+  ADD COLUMN `visibility` varchar(10) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'public' AFTER `language`;
+
+-- The existing snippets are given a random slug made like those of the new
+-- ones, 12 random bytes in URL-safe base64.
+UPDATE `snippets` SET `slug` = REPLACE(REPLACE(TO_BASE64(RANDOM_BYTES(12)), '+', '-'), '/', '_');
+
+ALTER TABLE `snippets`
+  MODIFY `slug` char(16) COLLATE utf8mb4_bin NOT NULL,
+  ADD UNIQUE KEY `snippets_uc_slug` (`slug`);
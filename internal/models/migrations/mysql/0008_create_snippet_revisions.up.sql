@@ -0,0 +1,18 @@
+CREATE TABLE `snippet_revisions` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `snippet_id` int NOT NULL,
+  `number` int NOT NULL,
+  `user_id` int NOT NULL,
+  `title` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `content` text COLLATE utf8mb4_unicode_ci NOT NULL,
+  `created` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `snippet_revisions_uc_number` (`snippet_id`, `number`),
+  KEY `idx_snippet_revisions_user_id` (`user_id`),
+  CONSTRAINT `fk_snippet_revisions_snippet_id` FOREIGN KEY (`snippet_id`) REFERENCES `snippets` (`id`) ON DELETE CASCADE,
+  CONSTRAINT `fk_snippet_revisions_user_id` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- The history of the existing snippets starts with their current version.
+INSERT INTO `snippet_revisions` (`snippet_id`, `number`, `user_id`, `title`, `content`, `created`)
+SELECT `id`, 1, `user_id`, `title`, `content`, `created` FROM `snippets`;
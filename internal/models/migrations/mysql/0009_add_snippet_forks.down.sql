@@ -0,0 +1,3 @@
+ALTER TABLE `snippets` DROP FOREIGN KEY `fk_snippets_forked_from`;
+
+ALTER TABLE `snippets` DROP KEY `idx_snippets_forked_from`, DROP COLUMN `forked_from`;
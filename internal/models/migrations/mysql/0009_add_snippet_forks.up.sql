@@ -0,0 +1,4 @@
+ALTER TABLE `snippets`
+  ADD COLUMN `forked_from` int DEFAULT NULL AFTER `visibility`,
+  ADD KEY `idx_snippets_forked_from` (`forked_from`),
+  ADD CONSTRAINT `fk_snippets_forked_from` FOREIGN KEY (`forked_from`) REFERENCES `snippets` (`id`) ON DELETE SET NULL;
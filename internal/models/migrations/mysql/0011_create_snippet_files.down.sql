@@ -0,0 +1,20 @@
+-- The snippets only keep their first file, and the revisions the first file
+-- they saved.
+ALTER TABLE `snippets` DROP KEY `idx_snippets_fulltext`;
+
+ALTER TABLE `snippets`
+  ADD COLUMN `content` text COLLATE utf8mb4_unicode_ci NOT NULL AFTER `title`,
+  ADD COLUMN `language` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'plaintext' AFTER `content`;
+
+UPDATE `snippets` `s` INNER JOIN `snippet_files` `f` ON `f`.`snippet_id` = `s`.`id` AND `f`.`position` = 0
+SET `s`.`content` = `f`.`content`, `s`.`language` = `f`.`language`;
+
+ALTER TABLE `snippets` ADD FULLTEXT KEY `idx_snippets_fulltext` (`title`, `content`);
+
+ALTER TABLE `snippet_revisions` ADD COLUMN `content` text COLLATE utf8mb4_unicode_ci NOT NULL AFTER `title`;
+
+UPDATE `snippet_revisions` SET `content` = JSON_UNQUOTE(JSON_EXTRACT(`files`, '$[0].content'));
+
+ALTER TABLE `snippet_revisions` DROP COLUMN `files`;
+
+DROP TABLE snippet_files;
@@ -0,0 +1,40 @@
+CREATE TABLE `snippet_files` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `snippet_id` int NOT NULL,
+  `position` int NOT NULL,
+  `filename` varchar(100) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `language` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT 'plaintext',
+  `content` text COLLATE utf8mb4_unicode_ci NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `snippet_files_uc_position` (`snippet_id`, `position`),
+  UNIQUE KEY `snippet_files_uc_filename` (`snippet_id`, `filename`),
+  FULLTEXT KEY `idx_snippet_files_fulltext` (`filename`, `content`),
+  CONSTRAINT `fk_snippet_files_snippet_id` FOREIGN KEY (`snippet_id`) REFERENCES `snippets` (`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- The content of every snippet becomes its only file, named like the unnamed
+-- files of the snippet form.
+INSERT INTO `snippet_files` (`snippet_id`, `position`, `filename`, `language`, `content`)
+SELECT `id`, 0, CONCAT('file1', CASE `language`
+    WHEN 'bash' THEN '.sh' WHEN 'c' THEN '.c' WHEN 'cpp' THEN '.cpp' WHEN 'css' THEN '.css'
+    WHEN 'docker' THEN '.dockerfile' WHEN 'go' THEN '.go' WHEN 'html' THEN '.html' WHEN 'java' THEN '.java'
+    WHEN 'javascript' THEN '.js' WHEN 'json' THEN '.json' WHEN 'makefile' THEN '.mk' WHEN 'markdown' THEN '.md'
+    WHEN 'python' THEN '.py' WHEN 'rust' THEN '.rs' WHEN 'sql' THEN '.sql' WHEN 'typescript' THEN '.ts'
+    WHEN 'yaml' THEN '.yaml' ELSE '.txt' END), `language`, `content`
+FROM `snippets`;
+
+-- The revisions save their files as JSON, and the existing ones are given the
+-- name and language of the file of their snippet.
+ALTER TABLE `snippet_revisions` ADD COLUMN `files` mediumtext COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `title`;
+
+UPDATE `snippet_revisions` `r` INNER JOIN `snippet_files` `f` ON `f`.`snippet_id` = `r`.`snippet_id`
+SET `r`.`files` = JSON_ARRAY(JSON_OBJECT('name', `f`.`filename`, 'language', `f`.`language`, 'content', `r`.`content`));
+
+ALTER TABLE `snippet_revisions` MODIFY `files` mediumtext COLLATE utf8mb4_unicode_ci NOT NULL, DROP COLUMN `content`;
+
+ALTER TABLE `snippets` DROP KEY `idx_snippets_fulltext`;
+
+ALTER TABLE `snippets`
+  DROP COLUMN `content`,
+  DROP COLUMN `language`,
+  ADD FULLTEXT KEY `idx_snippets_fulltext` (`title`);
@@ -0,0 +1,4 @@
+-- The snippets which never expire have no expiry date.
+ALTER TABLE `snippets`
+  MODIFY `expires` datetime DEFAULT NULL,
+  ADD COLUMN `burn_after_reading` tinyint(1) NOT NULL DEFAULT '0' AFTER `expires`;
@@ -0,0 +1 @@
+ALTER TABLE `snippets` ADD KEY `idx_snippets_expires` (`expires`);
@@ -0,0 +1,17 @@
+-- The snippets created before their owner was recorded are given to an
+-- Anonymous account, which can't log in: its password hash is "!", which no
+-- password matches.
+INSERT INTO users (name, email, hashed_password, created)
+SELECT 'Anonymous', 'anonymous@snippetbox.invalid', '!', first.created
+FROM (SELECT MIN(created) AS created FROM snippets) AS first
+WHERE first.created IS NOT NULL;
+
+ALTER TABLE snippets ADD COLUMN user_id INTEGER;
+
+UPDATE snippets SET user_id = (SELECT id FROM users WHERE email = 'anonymous@snippetbox.invalid');
+
+ALTER TABLE snippets ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE snippets ADD CONSTRAINT fk_snippets_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
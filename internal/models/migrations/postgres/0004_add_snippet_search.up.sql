@@ -0,0 +1 @@
+CREATE INDEX idx_snippets_fulltext ON snippets USING GIN (to_tsvector('simple', title || ' ' || content));
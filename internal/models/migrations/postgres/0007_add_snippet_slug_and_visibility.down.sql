@@ -0,0 +1,3 @@
+ALTER TABLE snippets DROP COLUMN slug;
+
+ALTER TABLE snippets DROP COLUMN visibility;
@@ -0,0 +1,12 @@
+ALTER TABLE snippets ADD COLUMN slug CHAR(16);
+
+ALTER TABLE snippets ADD COLUMN visibility VARCHAR(10) NOT NULL DEFAULT 'public';
+
+-- The existing snippets are given a random slug made like those of the new
+-- ones, 12 random bytes in URL-safe base64. The bytes are taken from a random
+-- UUID, since gen_random_bytes needs the pgcrypto extension.
+UPDATE snippets SET slug = translate(encode(substring(uuid_send(gen_random_uuid()) FROM 1 FOR 12), 'base64'), '+/', '-_');
+
+ALTER TABLE snippets ALTER COLUMN slug SET NOT NULL;
+
+ALTER TABLE snippets ADD CONSTRAINT snippets_uc_slug UNIQUE (slug);
@@ -0,0 +1,24 @@
+-- The snippets only keep their first file, and the revisions the first file
+-- they saved.
+DROP INDEX idx_snippets_fulltext;
+
+ALTER TABLE snippets ADD COLUMN content TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE snippets ADD COLUMN language VARCHAR(20) NOT NULL DEFAULT 'plaintext';
+
+UPDATE snippets s SET content = f.content, language = f.language
+FROM snippet_files f WHERE f.snippet_id = s.id AND f.position = 0;
+
+ALTER TABLE snippets ALTER COLUMN content DROP DEFAULT;
+
+CREATE INDEX idx_snippets_fulltext ON snippets USING GIN (to_tsvector('simple', title || ' ' || content));
+
+ALTER TABLE snippet_revisions ADD COLUMN content TEXT NOT NULL DEFAULT '';
+
+UPDATE snippet_revisions SET content = files::json->0->>'content';
+
+ALTER TABLE snippet_revisions ALTER COLUMN content DROP DEFAULT;
+
+ALTER TABLE snippet_revisions DROP COLUMN files;
+
+DROP TABLE snippet_files;
@@ -0,0 +1,44 @@
+CREATE TABLE snippet_files (
+    id INTEGER NOT NULL PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    snippet_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL,
+    language VARCHAR(20) NOT NULL DEFAULT 'plaintext',
+    content TEXT NOT NULL,
+    CONSTRAINT snippet_files_uc_position UNIQUE (snippet_id, position),
+    CONSTRAINT snippet_files_uc_filename UNIQUE (snippet_id, filename),
+    CONSTRAINT fk_snippet_files_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_snippet_files_fulltext ON snippet_files USING GIN (to_tsvector('simple', filename || ' ' || content));
+
+-- The content of every snippet becomes its only file, named like the unnamed
+-- files of the snippet form.
+INSERT INTO snippet_files (snippet_id, position, filename, language, content)
+SELECT id, 0, 'file1' || CASE language
+    WHEN 'bash' THEN '.sh' WHEN 'c' THEN '.c' WHEN 'cpp' THEN '.cpp' WHEN 'css' THEN '.css'
+    WHEN 'docker' THEN '.dockerfile' WHEN 'go' THEN '.go' WHEN 'html' THEN '.html' WHEN 'java' THEN '.java'
+    WHEN 'javascript' THEN '.js' WHEN 'json' THEN '.json' WHEN 'makefile' THEN '.mk' WHEN 'markdown' THEN '.md'
+    WHEN 'python' THEN '.py' WHEN 'rust' THEN '.rs' WHEN 'sql' THEN '.sql' WHEN 'typescript' THEN '.ts'
+    WHEN 'yaml' THEN '.yaml' ELSE '.txt' END, language, content
+FROM snippets;
+
+-- The revisions save their files as JSON, and the existing ones are given the
+-- name and language of the file of their snippet.
+ALTER TABLE snippet_revisions ADD COLUMN files TEXT;
+
+UPDATE snippet_revisions r
+SET files = json_build_array(json_build_object('name', f.filename, 'language', f.language, 'content', r.content))::text
+FROM snippet_files f WHERE f.snippet_id = r.snippet_id;
+
+ALTER TABLE snippet_revisions ALTER COLUMN files SET NOT NULL;
+
+ALTER TABLE snippet_revisions DROP COLUMN content;
+
+DROP INDEX idx_snippets_fulltext;
+
+ALTER TABLE snippets DROP COLUMN content;
+
+ALTER TABLE snippets DROP COLUMN language;
+
+CREATE INDEX idx_snippets_fulltext ON snippets USING GIN (to_tsvector('simple', title));
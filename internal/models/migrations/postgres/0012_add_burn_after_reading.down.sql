@@ -0,0 +1,5 @@
+UPDATE snippets SET expires = '9999-12-31 23:59:59' WHERE expires IS NULL;
+
+ALTER TABLE snippets ALTER COLUMN expires SET NOT NULL;
+
+ALTER TABLE snippets DROP COLUMN burn_after_reading;
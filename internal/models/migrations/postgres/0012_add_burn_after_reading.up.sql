@@ -0,0 +1,4 @@
+-- The snippets which never expire have no expiry date.
+ALTER TABLE snippets ALTER COLUMN expires DROP NOT NULL;
+
+ALTER TABLE snippets ADD COLUMN burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,18 @@
+CREATE TABLE snippets_new (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL
+);
+
+INSERT INTO snippets_new (id, title, content, created, expires)
+SELECT id, title, content, created, expires FROM snippets;
+
+DROP TABLE snippets;
+
+ALTER TABLE snippets_new RENAME TO snippets;
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+DELETE FROM users WHERE email = 'anonymous@snippetbox.invalid';
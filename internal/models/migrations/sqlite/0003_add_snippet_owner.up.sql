@@ -0,0 +1,30 @@
+-- The snippets created before their owner was recorded are given to an
+-- Anonymous account, which can't log in: its password hash is "!", which no
+-- password matches.
+INSERT INTO users (name, email, hashed_password, created)
+SELECT 'Anonymous', 'anonymous@snippetbox.invalid', '!', first.created
+FROM (SELECT MIN(created) AS created FROM snippets) AS first
+WHERE first.created IS NOT NULL;
+
+-- SQLite can't add a NOT NULL foreign key to a table, which is rebuilt instead.
+CREATE TABLE snippets_new (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    CONSTRAINT fk_snippets_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO snippets_new (id, user_id, title, content, created, expires)
+SELECT id, (SELECT id FROM users WHERE email = 'anonymous@snippetbox.invalid'), title, content, created, expires
+FROM snippets;
+
+DROP TABLE snippets;
+
+ALTER TABLE snippets_new RENAME TO snippets;
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
@@ -0,0 +1 @@
+-- SQLite searches the snippets with LIKE, which needs no index.
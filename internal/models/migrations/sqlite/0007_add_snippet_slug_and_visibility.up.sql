@@ -0,0 +1,28 @@
+-- SQLite can't add a NOT NULL column without a default to a table, which is
+-- rebuilt instead. The existing snippets are given a random slug of 8 random
+-- bytes in hexadecimal, since SQLite has no base64 function: the hexadecimal
+-- digits are among the characters of the slugs of the new snippets.
+CREATE TABLE snippets_new (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    slug CHAR(16) NOT NULL,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(20) NOT NULL DEFAULT 'plaintext',
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public',
+    created DATETIME NOT NULL,
+    expires DATETIME NOT NULL,
+    CONSTRAINT snippets_uc_slug UNIQUE (slug),
+    CONSTRAINT fk_snippets_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+INSERT INTO snippets_new (id, slug, user_id, title, content, language, created, expires)
+SELECT id, hex(randomblob(8)), user_id, title, content, language, created, expires FROM snippets;
+
+DROP TABLE snippets;
+
+ALTER TABLE snippets_new RENAME TO snippets;
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
@@ -0,0 +1,4 @@
+ALTER TABLE snippets ADD COLUMN forked_from INTEGER
+    CONSTRAINT fk_snippets_forked_from REFERENCES snippets(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_snippets_forked_from ON snippets(forked_from);
@@ -0,0 +1,18 @@
+-- The snippets only keep their first file, and the revisions the first file
+-- they saved.
+ALTER TABLE snippets ADD COLUMN content TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE snippets ADD COLUMN language VARCHAR(20) NOT NULL DEFAULT 'plaintext';
+
+UPDATE snippets SET
+    content = (SELECT f.content FROM snippet_files f WHERE f.snippet_id = snippets.id AND f.position = 0),
+    language = (SELECT f.language FROM snippet_files f WHERE f.snippet_id = snippets.id AND f.position = 0)
+WHERE EXISTS (SELECT 1 FROM snippet_files f WHERE f.snippet_id = snippets.id AND f.position = 0);
+
+ALTER TABLE snippet_revisions ADD COLUMN content TEXT NOT NULL DEFAULT '';
+
+UPDATE snippet_revisions SET content = json_extract(files, '$[0].content');
+
+ALTER TABLE snippet_revisions DROP COLUMN files;
+
+DROP TABLE snippet_files;
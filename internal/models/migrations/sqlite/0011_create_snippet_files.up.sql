@@ -0,0 +1,37 @@
+CREATE TABLE snippet_files (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    snippet_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    filename VARCHAR(100) NOT NULL,
+    language VARCHAR(20) NOT NULL DEFAULT 'plaintext',
+    content TEXT NOT NULL,
+    CONSTRAINT snippet_files_uc_position UNIQUE (snippet_id, position),
+    CONSTRAINT snippet_files_uc_filename UNIQUE (snippet_id, filename),
+    CONSTRAINT fk_snippet_files_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+-- The content of every snippet becomes its only file, named like the unnamed
+-- files of the snippet form.
+INSERT INTO snippet_files (snippet_id, position, filename, language, content)
+SELECT id, 0, 'file1' || CASE language
+    WHEN 'bash' THEN '.sh' WHEN 'c' THEN '.c' WHEN 'cpp' THEN '.cpp' WHEN 'css' THEN '.css'
+    WHEN 'docker' THEN '.dockerfile' WHEN 'go' THEN '.go' WHEN 'html' THEN '.html' WHEN 'java' THEN '.java'
+    WHEN 'javascript' THEN '.js' WHEN 'json' THEN '.json' WHEN 'makefile' THEN '.mk' WHEN 'markdown' THEN '.md'
+    WHEN 'python' THEN '.py' WHEN 'rust' THEN '.rs' WHEN 'sql' THEN '.sql' WHEN 'typescript' THEN '.ts'
+    WHEN 'yaml' THEN '.yaml' ELSE '.txt' END, language, content
+FROM snippets;
+
+-- The revisions save their files as JSON, and the existing ones are given the
+-- name and language of the file of their snippet.
+ALTER TABLE snippet_revisions ADD COLUMN files TEXT NOT NULL DEFAULT '';
+
+UPDATE snippet_revisions SET files = (
+    SELECT json_array(json_object('name', f.filename, 'language', f.language, 'content', snippet_revisions.content))
+    FROM snippet_files f WHERE f.snippet_id = snippet_revisions.snippet_id
+);
+
+ALTER TABLE snippet_revisions DROP COLUMN content;
+
+ALTER TABLE snippets DROP COLUMN content;
+
+ALTER TABLE snippets DROP COLUMN language;
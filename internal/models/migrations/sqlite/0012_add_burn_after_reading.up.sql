@@ -0,0 +1,29 @@
+-- The snippets which never expire have no expiry date. SQLite can't drop the
+-- NOT NULL constraint of a column, so the table is rebuilt.
+CREATE TABLE snippets_new (
+    id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    slug CHAR(16) NOT NULL,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public',
+    forked_from INTEGER,
+    created DATETIME NOT NULL,
+    expires DATETIME,
+    burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE,
+    CONSTRAINT snippets_uc_slug UNIQUE (slug),
+    CONSTRAINT fk_snippets_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_snippets_forked_from FOREIGN KEY (forked_from) REFERENCES snippets(id) ON DELETE SET NULL
+);
+
+INSERT INTO snippets_new (id, slug, user_id, title, visibility, forked_from, created, expires)
+SELECT id, slug, user_id, title, visibility, forked_from, created, expires FROM snippets;
+
+DROP TABLE snippets;
+
+ALTER TABLE snippets_new RENAME TO snippets;
+
+CREATE INDEX idx_snippets_created ON snippets(created);
+
+CREATE INDEX idx_snippets_user_id ON snippets(user_id);
+
+CREATE INDEX idx_snippets_forked_from ON snippets(forked_from);
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	want, err := loadMigrations(DriverMySQL)
+	assert.NilError(t, err)
+
+	// Every backend must have the same migrations, in the same order.
+	for _, driver := range Drivers {
+		t.Run(driver, func(t *testing.T) {
+			migrations, err := loadMigrations(driver)
+			assert.NilError(t, err)
+			assert.Equal(t, len(migrations), len(want))
+
+			for i, migration := range migrations {
+				assert.Equal(t, migration.Version, i+1)
+				assert.Equal(t, migration.String(), want[i].String())
+			}
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := Migrator{DB: db, Dialect: dialect}
+		ctx := context.Background()
+
+		migrations, err := m.Status(ctx)
+		assert.NilError(t, err)
+		for _, migration := range migrations {
+			assert.Equal(t, migration.Applied.IsZero(), false)
+		}
+
+		// Nothing is pending after newTestDB.
+		done, err := m.Up(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, len(done), 0)
+
+		last := migrations[len(migrations)-1]
+		done, err = m.Down(ctx, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, len(done), 1)
+		assert.Equal(t, done[0].Version, last.Version)
+
+		migrations, err = m.Status(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, migrations[len(migrations)-1].Applied.IsZero(), true)
+
+		done, err = m.Up(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, len(done), 1)
+		assert.Equal(t, done[0].Version, last.Version)
+
+		// A database migrated by a newer binary is refused.
+		_, err = db.Exec(`INSERT INTO schema_migrations (version, name, applied) VALUES (9999, 'future', '2099-01-01 10:00:00')`)
+		assert.NilError(t, err)
+		_, err = m.Up(ctx)
+		assert.Equal(t, errors.Is(err, ErrUnknownMigration), true)
+
+		_, err = db.Exec(`DELETE FROM schema_migrations WHERE version = 9999`)
+		assert.NilError(t, err)
+	})
+}
+
+func TestMigratorConcurrentUp(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db, migrator := openTestDB(t, dialect)
+		other := &Migrator{DB: db, Dialect: dialect}
+
+		// Two replicas starting together apply every migration once between them.
+		var wg sync.WaitGroup
+		results := make([][]Migration, 2)
+		errs := make([]error, 2)
+		for i, m := range []*Migrator{migrator, other} {
+			wg.Add(1)
+			go func(i int, m *Migrator) {
+				defer wg.Done()
+				results[i], errs[i] = m.Up(context.Background())
+			}(i, m)
+		}
+		wg.Wait()
+
+		assert.NilError(t, errs[0])
+		assert.NilError(t, errs[1])
+
+		migrations, err := migrator.Status(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, len(results[0])+len(results[1]), len(migrations))
+	})
+}
+
+func TestMigrateFromBaseline(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db, migrator := openTestDB(t, dialect)
+		ctx := context.Background()
+
+		// Migration 0002 is the schema the former build/init.sql script set up,
+		// whose snippets had no owner, slug, files or revisions.
+		_, err := migrator.upTo(ctx, 2)
+		assert.NilError(t, err)
+
+		for _, statement := range []string{
+			`INSERT INTO users (name, email, hashed_password, created) VALUES ('Alice Jones', 'alice@example.com', '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG', '2022-01-01 10:00:00')`,
+			`INSERT INTO snippets (title, content, created, expires) VALUES ('An old silent pond', 'An old silent pond...', '2022-01-01 10:00:00', '2099-01-01 10:00:00')`,
+			`INSERT INTO snippets (title, content, created, expires) VALUES ('A world of dew', 'A world of dew...', '2022-01-02 10:00:00', '2099-01-01 10:00:00')`,
+		} {
+			_, err := db.Exec(statement)
+			assert.NilError(t, err)
+		}
+
+		done, err := migrator.Up(ctx)
+		assert.NilError(t, err)
+		assert.Equal(t, done[0].Version, 3)
+
+		m := SnippetModel{DB: db, Dialect: dialect}
+		snippet, err := m.Get(ctx, 1)
+		assert.NilError(t, err)
+		other, err := m.Get(ctx, 2)
+		assert.NilError(t, err)
+
+		// The snippets are given to the Anonymous account, and a random slug.
+		assert.Equal(t, snippet.UserName, "Anonymous")
+		assert.Equal(t, len(snippet.Slug), SLUG_LENGTH)
+		assert.Equal(t, snippet.Slug == other.Slug, false)
+		assert.Equal(t, snippet.Visibility, VisibilityPublic)
+
+		// Nobody can log in as the Anonymous account.
+		users := UserModel{DB: db, Dialect: dialect}
+		_, err = users.Authenticate(ctx, "anonymous@snippetbox.invalid", "")
+		assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+
+		// Their content becomes their only file, and their first revision.
+		assert.Equal(t, len(snippet.Files), 1)
+		assert.Equal(t, *snippet.Files[0], File{Name: "file1.txt", Language: "plaintext", Content: "An old silent pond..."})
+
+		revisions, err := m.Revisions(ctx, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 1)
+		assert.Equal(t, revisions[0].Number, 1)
+		assert.Equal(t, revisions[0].Title, "An old silent pond")
+		assert.Equal(t, len(revisions[0].Files), 1)
+		assert.Equal(t, *revisions[0].Files[0], *snippet.Files[0])
+
+		// The new snippets can be created and owned by the existing users.
+		snippet = &Snippet{UserID: 1, Title: "Over the wintry forest", Visibility: VisibilityPublic, Files: []*File{{Name: "winter.txt", Language: "plaintext", Content: "Over the wintry forest..."}}}
+		assert.NilError(t, m.Insert(ctx, snippet))
+		assert.Equal(t, snippet.ID, 3)
+
+		// Reverting the migrations brings the baseline schema back, without the
+		// Anonymous account.
+		_, err = migrator.Down(ctx, len(done))
+		assert.NilError(t, err)
+
+		var content string
+		err = db.QueryRow(`SELECT content FROM snippets WHERE id = 2`).Scan(&content)
+		assert.NilError(t, err)
+		assert.Equal(t, content, "A world of dew...")
+
+		var count int
+		err = db.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count)
+		assert.NilError(t, err)
+		assert.Equal(t, count, 1)
+	})
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `-- Comments are dropped.
+CREATE TABLE a (
+    id INTEGER NOT NULL
+);
+
+CREATE INDEX idx_a ON a(id);
+INSERT INTO a VALUES (1)`
+
+	statements := splitStatements(script)
+	assert.Equal(t, len(statements), 3)
+	assert.Equal(t, statements[0], "CREATE TABLE a (\n    id INTEGER NOT NULL\n);")
+	assert.Equal(t, statements[1], "CREATE INDEX idx_a ON a(id);")
+	assert.Equal(t, statements[2], "INSERT INTO a VALUES (1)")
+}
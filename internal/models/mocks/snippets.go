@@ -1,34 +1,317 @@
 package mocks
 
 import (
+	"context"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/vladComan0/go-snippets/internal/models"
 )
 
+// Slugs of the unlisted snippets known to the mock SnippetModel.
+const (
+	MockUnlistedSlug = "kS2vX9qLb7TzWc0d"
+	MockBurnSlug     = "Bt7yU4iO1pA8sD2f"
+	MockBurntSlug    = "Hk3jG6fD9sA2qW5e"
+)
+
+var (
+	mockExpires = time.Now().Add(365 * 24 * time.Hour)
+	mockExpired = time.Now().Add(-24 * time.Hour)
+)
+
 var mockSnippet = &models.Snippet{
-	ID:      1,
-	Title:   "An old silent pond",
-	Content: "An old silent pond...",
-	Created: time.Now(),
-	Expires: time.Now(),
+	ID:       1,
+	Slug:     "fa6mQ2ZcLm1ZxQbO",
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "An old silent pond",
+	Files: []*models.File{
+		{Name: "pond.txt", Language: "plaintext", Content: "An old silent pond..."},
+		{Name: "frog.txt", Language: "plaintext", Content: "A frog jumps into the pond, splash! Silence again."},
+	},
+	Visibility: models.VisibilityPublic,
+	Tags:       []string{"haiku", "nature"},
+	Forks:      1,
+	Created:    time.Now(),
+	Expires:    &mockExpires,
+}
+
+var mockOtherSnippet = &models.Snippet{
+	ID:       3,
+	Slug:     "Jr8hN1pYe5UoQa3s",
+	UserID:   2,
+	UserName: "Bob",
+	Title:    "Over the wintry forest",
+	Files: []*models.File{
+		{Name: "forest.txt", Language: "plaintext", Content: "Over the wintry forest, winds howl in rage..."},
+	},
+	Visibility: models.VisibilityPublic,
+	Tags:       []string{"haiku"},
+	ForkedFrom: 1,
+	Created:    time.Now(),
+	Expires:    &mockExpires,
+}
+
+var mockUnlistedSnippet = &models.Snippet{
+	ID:       4,
+	Slug:     MockUnlistedSlug,
+	UserID:   2,
+	UserName: "Bob",
+	Title:    "First autumn morning",
+	Files: []*models.File{
+		{Name: "autumn.md", Language: "markdown", Content: "First autumn morning, the mirror I stare into...\n\n*[Basho](https://example.com/basho)*"},
+	},
+	Visibility: models.VisibilityUnlisted,
+	Tags:       []string{"haiku"},
+	Created:    time.Now(),
+	Expires:    &mockExpires,
+}
+
+var mockPrivateSnippet = &models.Snippet{
+	ID:       5,
+	Slug:     "Zq4wE6tRy8UiOp0a",
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "A world of dew",
+	Files: []*models.File{
+		{Name: "dew.txt", Language: "plaintext", Content: "A world of dew, and within every dewdrop..."},
+	},
+	Visibility: models.VisibilityPrivate,
+	Tags:       []string{"haiku"},
+	Created:    time.Now(),
+}
+
+var mockBurnSnippet = &models.Snippet{
+	ID:       6,
+	Slug:     MockBurnSlug,
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "A lightning flash",
+	Files: []*models.File{
+		{Name: "flash.txt", Language: "plaintext", Content: "A lightning flash, between the forest trees..."},
+	},
+	Visibility:       models.VisibilityUnlisted,
+	Tags:             []string{},
+	Created:          time.Now(),
+	Expires:          &mockExpires,
+	BurnAfterReading: true,
+}
+
+// mockBurntSnippet is a burn-after-reading snippet which someone else reads
+// concurrently: it's still returned by Get, but burning it fails.
+var mockBurntSnippet = &models.Snippet{
+	ID:       7,
+	Slug:     MockBurntSlug,
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "The light of a candle",
+	Files: []*models.File{
+		{Name: "candle.txt", Language: "plaintext", Content: "The light of a candle is transferred to another candle..."},
+	},
+	Visibility:       models.VisibilityUnlisted,
+	Tags:             []string{},
+	Created:          time.Now(),
+	Expires:          &mockExpires,
+	BurnAfterReading: true,
+}
+
+// mockExpiredSnippet has expired but hasn't been purged yet: only GetOwned
+// still returns it.
+var mockExpiredSnippet = &models.Snippet{
+	ID:       8,
+	Slug:     "Mx2cV5bN8mQ1wE4r",
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "Summer grasses",
+	Files: []*models.File{
+		{Name: "grasses.txt", Language: "plaintext", Content: "Summer grasses, all that remains of warriors' dreams."},
+	},
+	Visibility: models.VisibilityPublic,
+	Tags:       []string{},
+	Created:    time.Now().Add(-48 * time.Hour),
+	Expires:    &mockExpired,
+}
+
+var mockSnippets = []*models.Snippet{mockSnippet, mockOtherSnippet, mockUnlistedSnippet, mockPrivateSnippet, mockBurnSnippet, mockBurntSnippet}
+
+// mockRevisions holds the revisions of mockSnippet, newest first.
+var mockRevisions = []*models.Revision{
+	{
+		ID:        2,
+		SnippetID: 1,
+		Number:    2,
+		UserID:    1,
+		UserName:  "Alice",
+		Title:     "An old silent pond",
+		Files: []*models.File{
+			{Name: "pond.txt", Language: "plaintext", Content: "An old silent pond..."},
+			{Name: "frog.txt", Language: "plaintext", Content: "A frog jumps into the pond, splash! Silence again."},
+		},
+		Created: time.Now(),
+	},
+	{
+		ID:        1,
+		SnippetID: 1,
+		Number:    1,
+		UserID:    1,
+		UserName:  "Alice",
+		Title:     "An old silent pond",
+		Files: []*models.File{
+			{Name: "pond.txt", Language: "plaintext", Content: "An old silent pond."},
+		},
+		Created: time.Now(),
+	},
 }
 
 type SnippetModel struct{}
 
-func (m *SnippetModel) Insert(title, content string, expires int) (int, error) {
-	return 2, nil
+func (m *SnippetModel) Insert(ctx context.Context, snippet *models.Snippet) error {
+	snippet.ID = 2
+	snippet.Slug = "Nw1bV3cX5zL7kJ9h"
+	return nil
+}
+
+func (m *SnippetModel) Get(ctx context.Context, id int) (*models.Snippet, error) {
+	for _, snippet := range mockSnippets {
+		if snippet.ID == id {
+			return snippet, nil
+		}
+	}
+	return nil, models.ErrNoRecord
 }
 
-func (m *SnippetModel) Get(id int) (*models.Snippet, error) {
-	switch id {
+func (m *SnippetModel) GetOwned(ctx context.Context, id, userID int) (*models.Snippet, error) {
+	for _, snippet := range append(mockSnippets, mockExpiredSnippet) {
+		if snippet.ID == id && snippet.UserID == userID {
+			return snippet, nil
+		}
+	}
+	return nil, models.ErrNoRecord
+}
+
+func (m *SnippetModel) GetBySlug(ctx context.Context, slug string) (*models.Snippet, error) {
+	for _, snippet := range mockSnippets {
+		if snippet.Slug == slug {
+			return snippet, nil
+		}
+	}
+	return nil, models.ErrNoRecord
+}
+
+func (m *SnippetModel) Latest(ctx context.Context, filters models.Filters) ([]*models.Snippet, models.Metadata, error) {
+	return []*models.Snippet{mockSnippet}, models.Metadata{
+		CurrentPage:  filters.Page,
+		PageSize:     filters.PageSize,
+		FirstPage:    1,
+		LastPage:     1,
+		TotalRecords: 1,
+	}, nil
+}
+
+func (m *SnippetModel) ByUser(ctx context.Context, userID int, filters models.Filters) ([]*models.Snippet, models.Metadata, error) {
+	switch userID {
 	case 1:
-		return mockSnippet, nil
+		return []*models.Snippet{mockSnippet, mockPrivateSnippet}, models.Metadata{
+			CurrentPage:  filters.Page,
+			PageSize:     filters.PageSize,
+			FirstPage:    1,
+			LastPage:     1,
+			TotalRecords: 2,
+		}, nil
 	default:
-		return nil, models.ErrNoRecord
+		return []*models.Snippet{}, models.Metadata{}, nil
 	}
 }
 
-func (m *SnippetModel) Latest() ([]*models.Snippet, error) {
-	return []*models.Snippet{mockSnippet}, nil
+func (m *SnippetModel) Search(ctx context.Context, terms string, filters models.Filters) ([]*models.Snippet, models.Metadata, error) {
+	if !strings.Contains(strings.ToLower(mockSnippet.Text()), strings.ToLower(terms)) {
+		return []*models.Snippet{}, models.Metadata{}, nil
+	}
+
+	return []*models.Snippet{mockSnippet}, models.Metadata{
+		CurrentPage:  filters.Page,
+		PageSize:     filters.PageSize,
+		FirstPage:    1,
+		LastPage:     1,
+		TotalRecords: 1,
+	}, nil
+}
+
+func (m *SnippetModel) ByTag(ctx context.Context, tag string, filters models.Filters) ([]*models.Snippet, models.Metadata, error) {
+	snippets := []*models.Snippet{}
+	for _, snippet := range mockSnippets {
+		if snippet.Visibility == models.VisibilityPublic && slices.Contains(snippet.Tags, tag) {
+			snippets = append(snippets, snippet)
+		}
+	}
+	if len(snippets) == 0 {
+		return snippets, models.Metadata{}, nil
+	}
+
+	return snippets, models.Metadata{
+		CurrentPage:  filters.Page,
+		PageSize:     filters.PageSize,
+		FirstPage:    1,
+		LastPage:     1,
+		TotalRecords: len(snippets),
+	}, nil
+}
+
+func (m *SnippetModel) Update(ctx context.Context, snippet *models.Snippet, authorID int) error {
+	if _, err := m.Get(ctx, snippet.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *SnippetModel) Delete(ctx context.Context, id int) error {
+	if id == mockExpiredSnippet.ID {
+		return nil
+	}
+	if _, err := m.Get(ctx, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *SnippetModel) Burn(ctx context.Context, id int) error {
+	if id == mockBurnSnippet.ID {
+		return nil
+	}
+	return models.ErrNoRecord
+}
+
+func (m *SnippetModel) Extend(ctx context.Context, id, userID int, by time.Duration) error {
+	snippet, err := m.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if snippet.UserID != userID || snippet.Expires == nil {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+func (m *SnippetModel) DeleteExpired(ctx context.Context, batchSize int) (int, error) {
+	return 0, nil
+}
+
+func (m *SnippetModel) Revisions(ctx context.Context, snippetID int) ([]*models.Revision, error) {
+	switch snippetID {
+	case 1:
+		return mockRevisions, nil
+	default:
+		return []*models.Revision{}, nil
+	}
+}
+
+func (m *SnippetModel) Revision(ctx context.Context, snippetID, number int) (*models.Revision, error) {
+	for _, revision := range mockRevisions {
+		if revision.SnippetID == snippetID && revision.Number == number {
+			return revision, nil
+		}
+	}
+	return nil, models.ErrNoRecord
 }
@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/models"
+)
+
+// Plaintext values of the tokens known to the mock TokenModel.
+const (
+	MockToken         = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567ABCDEFGHIJKLMNOPQRST"
+	MockReadOnlyToken = "ZYXWVUTSRQPONMLKJIHGFEDCBA765432ZYXWVUTSRQPONMLKJIHG"
+)
+
+var mockToken = &models.Token{
+	ID:      1,
+	UserID:  1,
+	Name:    "CI",
+	Scopes:  models.Scopes,
+	Created: time.Now(),
+	Expires: time.Now().Add(30 * 24 * time.Hour),
+}
+
+var mockReadOnlyToken = &models.Token{
+	ID:      2,
+	UserID:  1,
+	Name:    "Dashboard",
+	Scopes:  []string{models.ScopeAccountRead},
+	Created: time.Now(),
+	Expires: time.Now().Add(30 * 24 * time.Hour),
+}
+
+type TokenModel struct{}
+
+func (m *TokenModel) New(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (*models.Token, error) {
+	return &models.Token{
+		ID:        3,
+		UserID:    userID,
+		Name:      name,
+		Plaintext: MockToken,
+		Scopes:    scopes,
+		Created:   time.Now(),
+		Expires:   time.Now().Add(ttl),
+	}, nil
+}
+
+func (m *TokenModel) GetForToken(ctx context.Context, plaintext string) (*models.Token, error) {
+	switch plaintext {
+	case MockToken:
+		return mockToken, nil
+	case MockReadOnlyToken:
+		return mockReadOnlyToken, nil
+	default:
+		return nil, models.ErrNoRecord
+	}
+}
+
+func (m *TokenModel) AllForUser(ctx context.Context, userID int) ([]*models.Token, error) {
+	switch userID {
+	case 1:
+		return []*models.Token{mockToken, mockReadOnlyToken}, nil
+	default:
+		return []*models.Token{}, nil
+	}
+}
+
+func (m *TokenModel) Delete(ctx context.Context, id, userID int) error {
+	if userID == 1 && (id == 1 || id == 2) {
+		return nil
+	}
+	return models.ErrNoRecord
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"time"
 
 	"github.com/vladComan0/go-snippets/internal/models"
@@ -8,7 +9,7 @@ import (
 
 type UserModel struct{}
 
-func (m *UserModel) Insert(name, email, password string) error {
+func (m *UserModel) Insert(ctx context.Context, name, email, password string) error {
 	switch email {
 	case "dupe@example.com":
 		return models.ErrDuplicateEmail
@@ -17,7 +18,7 @@ func (m *UserModel) Insert(name, email, password string) error {
 	}
 }
 
-func (m *UserModel) Authenticate(email, password string) (int, error) {
+func (m *UserModel) Authenticate(ctx context.Context, email, password string) (int, error) {
 	if email == "alice@example.com" && password == "pa$$word" {
 		return 1, nil
 	}
@@ -25,7 +26,7 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 	return 0, models.ErrInvalidCredentials
 }
 
-func (m *UserModel) Exists(id int) (bool, error) {
+func (m *UserModel) Exists(ctx context.Context, id int) (bool, error) {
 	switch id {
 	case 1:
 		return true, nil
@@ -34,7 +35,7 @@ func (m *UserModel) Exists(id int) (bool, error) {
 	}
 }
 
-func (m *UserModel) Get(id int) (*models.User, error) {
+func (m *UserModel) Get(ctx context.Context, id int) (*models.User, error) {
 	switch id {
 	case 1:
 		return &models.User{
@@ -47,7 +48,7 @@ func (m *UserModel) Get(id int) (*models.User, error) {
 	return nil, models.ErrNoRecord
 }
 
-func (m *UserModel) UpdatePassword(id int, currentPassword, newPassword string) error {
+func (m *UserModel) UpdatePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
 	switch id {
 	case 1:
 		if currentPassword != "pa$$word" {
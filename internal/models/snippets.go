@@ -1,48 +1,211 @@
 package models
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Visibility levels of a snippet.
+const (
+	// VisibilityPublic snippets are listed on the home page and in search results.
+	VisibilityPublic = "public"
+	// VisibilityUnlisted snippets are only reachable through their random slug.
+	VisibilityUnlisted = "unlisted"
+	// VisibilityPrivate snippets can only be seen by their owner.
+	VisibilityPrivate = "private"
+)
+
+var Visibilities = []string{VisibilityPublic, VisibilityUnlisted, VisibilityPrivate}
+
+// SLUG_LENGTH is the length of the random, URL-safe slug of every snippet.
+const SLUG_LENGTH = 16
+
 type SnippetModelInterface interface {
-	Insert(title, content string, expires int) (int, error)
-	Get(id int) (*Snippet, error)
-	Latest() ([]*Snippet, error)
+	Insert(ctx context.Context, snippet *Snippet) error
+	Get(ctx context.Context, id int) (*Snippet, error)
+	GetBySlug(ctx context.Context, slug string) (*Snippet, error)
+	GetOwned(ctx context.Context, id, userID int) (*Snippet, error)
+	Latest(ctx context.Context, filters Filters) ([]*Snippet, Metadata, error)
+	ByUser(ctx context.Context, userID int, filters Filters) ([]*Snippet, Metadata, error)
+	Search(ctx context.Context, terms string, filters Filters) ([]*Snippet, Metadata, error)
+	ByTag(ctx context.Context, tag string, filters Filters) ([]*Snippet, Metadata, error)
+	Update(ctx context.Context, snippet *Snippet, authorID int) error
+	Delete(ctx context.Context, id int) error
+	Burn(ctx context.Context, id int) error
+	Extend(ctx context.Context, id, userID int, by time.Duration) error
+	DeleteExpired(ctx context.Context, batchSize int) (int, error)
+	Revisions(ctx context.Context, snippetID int) ([]*Revision, error)
+	Revision(ctx context.Context, snippetID, number int) (*Revision, error)
 }
 
 type Snippet struct {
-	ID      int
-	Title   string
-	Content string
-	Created time.Time
-	Expires time.Time
+	ID         int       `json:"id"`
+	Slug       string    `json:"slug,omitempty"`
+	UserID     int       `json:"user_id"`
+	UserName   string    `json:"user_name"`
+	Title      string    `json:"title"`
+	Files      []*File   `json:"files"`
+	Visibility string    `json:"visibility"`
+	Tags       []string  `json:"tags"`
+	ForkedFrom int       `json:"forked_from,omitempty"`
+	Forks      int       `json:"forks"`
+	Created    time.Time `json:"created"`
+	// Expires is nil for snippets which never expire.
+	Expires *time.Time `json:"expires"`
+	// BurnAfterReading snippets are deleted the first time someone other than
+	// their owner views them.
+	BurnAfterReading bool `json:"burn_after_reading"`
+}
+
+// Expired reports whether the snippet's expiry date has already passed.
+func (s *Snippet) Expired() bool {
+	return s.Expires != nil && !s.Expires.After(time.Now())
+}
+
+// expiresSoonThreshold is how close to its expiry date a snippet has to be for
+// ExpiresSoon to report it.
+const expiresSoonThreshold = 3 * 24 * time.Hour
+
+// ExpiresSoon reports whether the snippet hasn't expired yet, but will within
+// the next few days.
+func (s *Snippet) ExpiresSoon() bool {
+	return s.Expires != nil && !s.Expired() && time.Until(*s.Expires) < expiresSoonThreshold
+}
+
+// Text returns the content of all the files of the snippet.
+func (s *Snippet) Text() string {
+	contents := make([]string, len(s.Files))
+	for i, file := range s.Files {
+		contents[i] = file.Content
+	}
+	return strings.Join(contents, "\n\n")
+}
+
+// File returns the file of the snippet with the given name, or nil if there is
+// no such file.
+func (s *Snippet) File(name string) *File {
+	for _, file := range s.Files {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
+}
+
+// Ref returns the value identifying the snippet in URLs. Unlisted snippets are
+// only reachable through their slug, everything else uses the numeric ID.
+func (s *Snippet) Ref() string {
+	if s.Visibility == VisibilityUnlisted {
+		return s.Slug
+	}
+	return strconv.Itoa(s.ID)
+}
+
+// File is one of the named files making up a snippet, in the order they are shown.
+type File struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// Revision is a saved version of the title and files of a snippet. Revisions are
+// numbered from 1 for every snippet, the highest number being the current one.
+type Revision struct {
+	ID        int       `json:"-"`
+	SnippetID int       `json:"snippet_id"`
+	Number    int       `json:"number"`
+	UserID    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Title     string    `json:"title"`
+	Files     []*File   `json:"files"`
+	Created   time.Time `json:"created"`
+}
+
+// File returns the file of the revision with the given name, or nil if there is
+// no such file.
+func (r *Revision) File(name string) *File {
+	for _, file := range r.Files {
+		if file.Name == name {
+			return file
+		}
+	}
+	return nil
 }
 
 type SnippetModel struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
 }
 
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
-	query := `INSERT INTO snippetbox.snippets(title, content, created, expires) 
-	VALUES(?, ?, UTC_TIMESTAMP(),DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY))`
-	result, err := m.DB.Exec(query, title, content, expires)
-	if err != nil {
-		return 0, err
+func (m *SnippetModel) db() conn {
+	return conn{m.DB, m.Dialect}
+}
+
+// snippetColumns are the columns selected by every snippet query, in the order
+// expected by scanSnippet. Queries must join the users table as u.
+const snippetColumns = `s.id, s.slug, s.user_id, u.name, s.title, s.visibility,
+	COALESCE(s.forked_from, 0), (SELECT COUNT(*) FROM snippets f WHERE f.forked_from = s.id), s.created, s.expires, s.burn_after_reading`
+
+// notExpired matches the snippets which can still be viewed, given the current
+// time as argument. A NULL expiry date means the snippet never expires.
+const notExpired = `(s.expires IS NULL OR s.expires > ?)`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSnippet scans a row selected with snippetColumns, preceded by any extra
+// destinations (e.g. a window function count).
+func scanSnippet(row scanner, extra ...any) (*Snippet, error) {
+	s := &Snippet{}
+	dest := append(extra, &s.ID, &s.Slug, &s.UserID, &s.UserName, &s.Title, &s.Visibility, &s.ForkedFrom, &s.Forks, &s.Created, &s.Expires, &s.BurnAfterReading)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
 	}
-	id, err := result.LastInsertId()
+	return s, nil
+}
+
+// list runs a paginated listing query, which must select COUNT(*) OVER() followed
+// by snippetColumns, and returns the snippets together with their pagination metadata.
+func (m *SnippetModel) list(ctx context.Context, filters Filters, query string, args ...any) ([]*Snippet, Metadata, error) {
+	rows, err := m.db().QueryContext(ctx, query, append(args, filters.limit(), filters.offset())...)
 	if err != nil {
-		return 0, err
+		return nil, Metadata{}, err
 	}
-	return int(id), nil
+	defer rows.Close()
+	totalRecords := 0
+	snippets := []*Snippet{}
+	for rows.Next() {
+		s, err := scanSnippet(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		snippets = append(snippets, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+	if err := m.loadFiles(ctx, snippets...); err != nil {
+		return nil, Metadata{}, err
+	}
+	if err := m.loadTags(ctx, snippets...); err != nil {
+		return nil, Metadata{}, err
+	}
+	return snippets, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
 }
 
-func (m *SnippetModel) Get(id int) (*Snippet, error) {
-	query := `SELECT id, title, content, created, expires FROM snippets
-	WHERE expires > UTC_TIMESTAMP() AND id = ?`
-	s := &Snippet{}
-	if err := m.DB.QueryRow(query, id).Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires); err != nil { // errors from DB.QueryRow() are deferred until Scan() is called, so we can use a oneliner
+// get returns the single snippet selected by a query on snippetColumns, with its
+// files and tags.
+func (m *SnippetModel) get(ctx context.Context, query string, args ...any) (*Snippet, error) {
+	s, err := scanSnippet(m.db().QueryRowContext(ctx, query, args...)) // errors from DB.QueryRow() are deferred until Scan() is called
+	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrNoRecord
@@ -50,28 +213,564 @@ func (m *SnippetModel) Get(id int) (*Snippet, error) {
 			return nil, err
 		}
 	}
+	if err := m.loadFiles(ctx, s); err != nil {
+		return nil, err
+	}
+	if err := m.loadTags(ctx, s); err != nil {
+		return nil, err
+	}
 	return s, nil
 }
 
-func (m *SnippetModel) Latest() ([]*Snippet, error) {
-	query := `SELECT id, title, content, created, expires FROM snippets
-	WHERE expires > UTC_TIMESTAMP() ORDER BY id DESC LIMIT 10`
-	rows, err := m.DB.Query(query)
+// placeholders returns n comma-separated placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// snippetIDs indexes the given snippets by ID, and returns their IDs as query
+// arguments.
+func snippetIDs(snippets []*Snippet) (map[int]*Snippet, []any) {
+	byID := make(map[int]*Snippet, len(snippets))
+	args := make([]any, len(snippets))
+	for i, s := range snippets {
+		byID[s.ID] = s
+		args[i] = s.ID
+	}
+	return byID, args
+}
+
+// loadFiles fetches the files of the given snippets with a single query.
+func (m *SnippetModel) loadFiles(ctx context.Context, snippets ...*Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	byID, args := snippetIDs(snippets)
+	for _, s := range snippets {
+		s.Files = []*File{}
+	}
+
+	query := `SELECT snippet_id, filename, language, content FROM snippet_files
+	WHERE snippet_id IN (` + placeholders(len(args)) + `)
+	ORDER BY snippet_id, position`
+	rows, err := m.db().QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var snippetID int
+		f := &File{}
+		if err := rows.Scan(&snippetID, &f.Name, &f.Language, &f.Content); err != nil {
+			return err
+		}
+		byID[snippetID].Files = append(byID[snippetID].Files, f)
+	}
+	return rows.Err()
+}
+
+// loadTags fetches the tags of the given snippets with a single query, sorted
+// by name.
+func (m *SnippetModel) loadTags(ctx context.Context, snippets ...*Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	byID, args := snippetIDs(snippets)
+	for _, s := range snippets {
+		s.Tags = []string{}
+	}
+
+	query := `SELECT st.snippet_id, t.name FROM snippet_tags st INNER JOIN tags t ON t.id = st.tag_id
+	WHERE st.snippet_id IN (` + placeholders(len(args)) + `)
+	ORDER BY st.snippet_id, t.name`
+	rows, err := m.db().QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			snippetID int
+			tag       string
+		)
+		if err := rows.Scan(&snippetID, &tag); err != nil {
+			return err
+		}
+		byID[snippetID].Tags = append(byID[snippetID].Tags, tag)
+	}
+	return rows.Err()
+}
+
+// setFiles replaces the files of the given snippet.
+func setFiles(ctx context.Context, tx conn, snippetID int, files []*File) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM snippet_files WHERE snippet_id = ?`, snippetID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO snippet_files(snippet_id, position, filename, language, content) VALUES(?, ?, ?, ?, ?)`
+	for i, file := range files {
+		if _, err := tx.ExecContext(ctx, query, snippetID, i, file.Name, file.Language, file.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTags replaces the tags of the given snippet, creating the tags that don't
+// exist yet. Tag names are expected to be normalized and free of commas.
+func setTags(ctx context.Context, tx conn, snippetID int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM snippet_tags WHERE snippet_id = ?`, snippetID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	args := make([]any, len(tags))
+	for i, tag := range tags {
+		args[i] = tag
+	}
+
+	query := tx.dialect.insertIgnore("tags", "name", strings.TrimSuffix(strings.Repeat("(?),", len(tags)), ","))
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO snippet_tags(snippet_id, tag_id)
+	SELECT s.id, t.id FROM snippets s, tags t WHERE s.id = ? AND t.name IN (` + placeholders(len(tags)) + `)`
+	if _, err := tx.ExecContext(ctx, query, append([]any{snippetID}, args...)...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// generateSlug returns a random string of SLUG_LENGTH URL-safe characters.
+func generateSlug() (string, error) {
+	randomBytes := make([]byte, SLUG_LENGTH*3/4)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(randomBytes), nil
+}
+
+// Insert adds a new snippet owned by snippet.UserID, together with its first
+// revision. snippet.Expires is the date the snippet expires at, or nil if it
+// never does, and snippet.ForkedFrom is the ID of the snippet it was forked from,
+// if any. The ID and slug of the new snippet are set on snippet.
+func (m *SnippetModel) Insert(ctx context.Context, snippet *Snippet) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	slug, err := generateSlug()
+	if err != nil {
+		return err
+	}
+
+	sqlTx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	tx := conn{sqlTx, m.Dialect}
+
+	forkedFrom := sql.NullInt64{Int64: int64(snippet.ForkedFrom), Valid: snippet.ForkedFrom != 0}
+
+	var expires sql.NullTime
+	if snippet.Expires != nil {
+		expires = sql.NullTime{Time: snippet.Expires.UTC(), Valid: true}
+	}
+
+	now := time.Now().UTC()
+	query := `INSERT INTO snippets(slug, user_id, title, visibility, forked_from, created, expires, burn_after_reading)
+	VALUES(?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := m.Dialect.insertID(ctx, tx, query, slug, snippet.UserID, snippet.Title, snippet.Visibility, forkedFrom, now, expires, snippet.BurnAfterReading)
+	if err != nil {
+		return err
+	}
+
+	if err := setFiles(ctx, tx, id, snippet.Files); err != nil {
+		return err
+	}
+
+	files, err := json.Marshal(snippet.Files)
+	if err != nil {
+		return err
+	}
+
+	query = `INSERT INTO snippet_revisions(snippet_id, number, user_id, title, files, created)
+	VALUES(?, 1, ?, ?, ?, ?)`
+	if _, err := tx.ExecContext(ctx, query, id, snippet.UserID, snippet.Title, string(files), now); err != nil {
+		return err
+	}
+
+	if err := setTags(ctx, tx, id, snippet.Tags); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+	snippet.ID = id
+	snippet.Slug = slug
+	return nil
+}
+
+// Get returns the non-expired snippet with the given ID, whatever its visibility.
+// It's up to the caller to check that the current user may see it.
+func (m *SnippetModel) Get(ctx context.Context, id int) (*Snippet, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	WHERE ` + notExpired + ` AND s.id = ?`
+	return m.get(ctx, query, time.Now().UTC(), id)
+}
+
+// GetOwned returns a snippet of the given user, even if it has expired, so that
+// its owner can still renew or delete it until it is purged. It returns
+// ErrNoRecord if the user has no such snippet.
+func (m *SnippetModel) GetOwned(ctx context.Context, id, userID int) (*Snippet, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	WHERE s.id = ? AND s.user_id = ?`
+	return m.get(ctx, query, id, userID)
+}
+
+// GetBySlug is like Get, but looks the snippet up by its slug.
+func (m *SnippetModel) GetBySlug(ctx context.Context, slug string) (*Snippet, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	WHERE ` + notExpired + ` AND s.slug = ?`
+	return m.get(ctx, query, time.Now().UTC(), slug)
+}
+
+// Latest returns a page of the non-expired public snippets, newest first.
+// Burn-after-reading snippets are left out of this and the other public
+// listings, so that they are only read by whoever was given their link.
+func (m *SnippetModel) Latest(ctx context.Context, filters Filters) ([]*Snippet, Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT COUNT(*) OVER(), ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	WHERE ` + notExpired + ` AND s.visibility = ? AND NOT s.burn_after_reading
+	ORDER BY s.created DESC, s.id DESC LIMIT ? OFFSET ?`
+	return m.list(ctx, filters, query, time.Now().UTC(), VisibilityPublic)
+}
+
+// ByUser returns a page of the snippets created by the given user, newest first.
+// Unlike Latest, expired and non-public snippets are included so their owner can
+// still manage them.
+func (m *SnippetModel) ByUser(ctx context.Context, userID int, filters Filters) ([]*Snippet, Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT COUNT(*) OVER(), ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	WHERE s.user_id = ? ORDER BY s.created DESC, s.id DESC LIMIT ? OFFSET ?`
+	return m.list(ctx, filters, query, userID)
+}
+
+// Search returns a page of the non-expired public snippets whose title or files
+// match the given terms, ordered by relevance. How the terms are matched and
+// scored depends on the dialect.
+func (m *SnippetModel) Search(ctx context.Context, terms string, filters Filters) ([]*Snippet, Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	matches, args := m.Dialect.searchMatches(terms)
+	query := `SELECT COUNT(*) OVER(), ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	INNER JOIN (
+		SELECT snippet_id, SUM(score) AS score FROM (
+			` + matches + `
+		) matches GROUP BY snippet_id
+	) m ON m.snippet_id = s.id
+	WHERE ` + notExpired + ` AND s.visibility = ? AND NOT s.burn_after_reading
+	ORDER BY m.score DESC, s.id DESC LIMIT ? OFFSET ?`
+	return m.list(ctx, filters, query, append(args, time.Now().UTC(), VisibilityPublic)...)
+}
+
+// ByTag returns a page of the non-expired public snippets with the given tag,
+// newest first.
+func (m *SnippetModel) ByTag(ctx context.Context, tag string, filters Filters) ([]*Snippet, Metadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT COUNT(*) OVER(), ` + snippetColumns + `
+	FROM snippets s INNER JOIN users u ON u.id = s.user_id
+	INNER JOIN snippet_tags st ON st.snippet_id = s.id
+	INNER JOIN tags t ON t.id = st.tag_id
+	WHERE ` + notExpired + ` AND s.visibility = ? AND NOT s.burn_after_reading AND t.name = ?
+	ORDER BY s.created DESC, s.id DESC LIMIT ? OFFSET ?`
+	return m.list(ctx, filters, query, time.Now().UTC(), VisibilityPublic, tag)
+}
+
+// Update saves the title, files, visibility and tags of the snippet. If the title
+// or files changed, a new revision authored by the given user is recorded. A
+// snippet which becomes unlisted is given a new slug, which is set on snippet,
+// since its former slug may have been published while it was public.
+func (m *SnippetModel) Update(ctx context.Context, snippet *Snippet, authorID int) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	sqlTx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	tx := conn{sqlTx, m.Dialect}
+
+	var visibility, slug string
+	query := `SELECT visibility, slug FROM snippets WHERE id = ?` + m.Dialect.forUpdate()
+	if err := tx.QueryRowContext(ctx, query, snippet.ID).Scan(&visibility, &slug); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		}
+		return err
+	}
+
+	if snippet.Visibility == VisibilityUnlisted && visibility != VisibilityUnlisted {
+		if slug, err = generateSlug(); err != nil {
+			return err
+		}
+	}
+
+	query = `UPDATE snippets SET slug = ?, title = ?, visibility = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, slug, snippet.Title, snippet.Visibility, snippet.ID); err != nil {
+		return err
+	}
+
+	if err := setFiles(ctx, tx, snippet.ID, snippet.Files); err != nil {
+		return err
+	}
+
+	if err := setTags(ctx, tx, snippet.ID, snippet.Tags); err != nil {
+		return err
+	}
+
+	// Lock the latest revision so that concurrent updates can't both claim the
+	// next revision number.
+	var (
+		number       int
+		title, saved string
+	)
+	query = `SELECT number, title, files FROM snippet_revisions
+	WHERE snippet_id = ? ORDER BY number DESC LIMIT 1` + m.Dialect.forUpdate()
+	err = tx.QueryRowContext(ctx, query, snippet.ID).Scan(&number, &title, &saved)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	files, err := json.Marshal(snippet.Files)
+	if err != nil {
+		return err
+	}
+
+	// The saved files are decoded rather than compared with their encoding,
+	// since the revisions backfilled by the migrations weren't encoded by Go.
+	if number == 0 || title != snippet.Title || !savedFiles(saved, snippet.Files) {
+		query = `INSERT INTO snippet_revisions(snippet_id, number, user_id, title, files, created)
+		VALUES(?, ?, ?, ?, ?, ?)`
+		if _, err := tx.ExecContext(ctx, query, snippet.ID, number+1, authorID, snippet.Title, string(files), time.Now().UTC()); err != nil {
+			return err
+		}
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+	snippet.Slug = slug
+	return nil
+}
+
+func (m *SnippetModel) Delete(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM snippets WHERE id = ?`
+	result, err := m.db().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
+
+// Burn deletes a burn-after-reading snippet once it has been read. Only one of
+// several concurrent readers gets a nil error, the others get ErrNoRecord and
+// must not be shown the snippet.
+func (m *SnippetModel) Burn(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `DELETE FROM snippets WHERE id = ? AND burn_after_reading`
+	result, err := m.db().ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
+
+// Extend pushes the expiry date of a snippet owned by the given user forward by
+// the given duration. Snippets which have already expired, but haven't been
+// purged yet, are renewed from now on. It returns ErrNoRecord if the user has no
+// such snippet, or if the snippet never expires.
+func (m *SnippetModel) Extend(ctx context.Context, id, userID int, by time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	sqlTx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	tx := conn{sqlTx, m.Dialect}
+
+	// The new expiry date is computed here rather than in SQL, where date
+	// arithmetic differs between every dialect.
+	var expires time.Time
+	query := `SELECT expires FROM snippets WHERE id = ? AND user_id = ? AND expires IS NOT NULL` + m.Dialect.forUpdate()
+	if err := tx.QueryRowContext(ctx, query, id, userID).Scan(&expires); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrNoRecord
+		default:
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	if expires.Before(now) {
+		expires = now
+	}
+
+	query = `UPDATE snippets SET expires = ? WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, expires.UTC().Add(by), id); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
+
+// DeleteExpired deletes up to batchSize expired snippets, together with their
+// files, revisions and tags, and returns how many were deleted. Forks of the
+// deleted snippets are kept.
+func (m *SnippetModel) DeleteExpired(ctx context.Context, batchSize int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	// Only MySQL supports DELETE ... LIMIT, and it doesn't support LIMIT in
+	// IN subqueries, unless they select from a derived table.
+	query := `DELETE FROM snippets WHERE id IN (
+		SELECT id FROM (SELECT id FROM snippets WHERE expires <= ? LIMIT ?) batch
+	)`
+	result, err := m.db().ExecContext(ctx, query, time.Now().UTC(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAffected), nil
+}
+
+// savedFiles reports whether the JSON encoded files of a revision are the given
+// files.
+func savedFiles(saved string, files []*File) bool {
+	var revision []*File
+	if err := json.Unmarshal([]byte(saved), &revision); err != nil || len(revision) != len(files) {
+		return false
+	}
+	for i := range files {
+		if *revision[i] != *files[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// revisionColumns are the columns selected by every revision query, in the order
+// expected by scanRevision.
+const revisionColumns = `r.id, r.snippet_id, r.number, r.user_id, u.name, r.title, r.files, r.created`
+
+func scanRevision(row scanner) (*Revision, error) {
+	r := &Revision{}
+	var files []byte
+	if err := row.Scan(&r.ID, &r.SnippetID, &r.Number, &r.UserID, &r.UserName, &r.Title, &files, &r.Created); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(files, &r.Files); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Revisions returns every revision of the given snippet, newest first.
+func (m *SnippetModel) Revisions(ctx context.Context, snippetID int) ([]*Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + revisionColumns + `
+	FROM snippet_revisions r INNER JOIN users u ON u.id = r.user_id
+	WHERE r.snippet_id = ? ORDER BY r.number DESC`
+	rows, err := m.db().QueryContext(ctx, query, snippetID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	snippets := []*Snippet{}
+	revisions := []*Revision{}
 	for rows.Next() {
-		s := &Snippet{}
-		err := rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+		r, err := scanRevision(rows)
 		if err != nil {
 			return nil, err
 		}
-		snippets = append(snippets, s)
+		revisions = append(revisions, r)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return snippets, nil
+	return revisions, nil
+}
+
+// Revision returns the revision of the given snippet with the given number.
+func (m *SnippetModel) Revision(ctx context.Context, snippetID, number int) (*Revision, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `SELECT ` + revisionColumns + `
+	FROM snippet_revisions r INNER JOIN users u ON u.id = r.user_id
+	WHERE r.snippet_id = ? AND r.number = ?`
+	r, err := scanRevision(m.db().QueryRowContext(ctx, query, snippetID, number))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNoRecord
+		default:
+			return nil, err
+		}
+	}
+	return r, nil
 }
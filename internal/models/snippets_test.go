@@ -0,0 +1,464 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestSnippetModelGet(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		tests := []struct {
+			name      string
+			snippetID int
+			wantOwner string
+			wantErr   error
+		}{
+			{
+				name:      "Valid ID",
+				snippetID: 1,
+				wantOwner: "Alice Jones",
+			},
+			{
+				name:      "Non-existent ID",
+				snippetID: 5,
+				wantErr:   ErrNoRecord,
+			},
+		}
+
+		for _, subtest := range tests {
+			t.Run(subtest.name, func(t *testing.T) {
+				db := newTestDB(t, dialect)
+				m := SnippetModel{DB: db, Dialect: dialect}
+
+				snippet, err := m.Get(context.Background(), subtest.snippetID)
+
+				assert.Equal(t, errors.Is(err, subtest.wantErr), true)
+				if snippet != nil {
+					assert.Equal(t, snippet.UserID, 1)
+					assert.Equal(t, snippet.UserName, subtest.wantOwner)
+				}
+			})
+		}
+	})
+}
+
+func TestSnippetModelGetBySlug(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		tests := []struct {
+			name           string
+			slug           string
+			wantID         int
+			wantVisibility string
+			wantErr        error
+		}{
+			{
+				name:           "Public snippet",
+				slug:           "fa6mQ2ZcLm1ZxQbO",
+				wantID:         1,
+				wantVisibility: VisibilityPublic,
+			},
+			{
+				name:           "Private snippet",
+				slug:           "Zq4wE6tRy8UiOp0a",
+				wantID:         2,
+				wantVisibility: VisibilityPrivate,
+			},
+			{
+				name:    "Slugs are case-sensitive",
+				slug:    "FA6MQ2ZCLM1ZXQBO",
+				wantErr: ErrNoRecord,
+			},
+		}
+
+		for _, subtest := range tests {
+			t.Run(subtest.name, func(t *testing.T) {
+				db := newTestDB(t, dialect)
+				m := SnippetModel{DB: db, Dialect: dialect}
+
+				snippet, err := m.GetBySlug(context.Background(), subtest.slug)
+
+				assert.Equal(t, errors.Is(err, subtest.wantErr), true)
+				if snippet != nil {
+					assert.Equal(t, snippet.ID, subtest.wantID)
+					assert.Equal(t, snippet.Visibility, subtest.wantVisibility)
+				}
+			})
+		}
+	})
+}
+
+func TestSnippetModelGetOwned(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		expires := time.Now().Add(-time.Hour)
+		expired := &Snippet{
+			UserID: 1,
+			Title:  "A forgotten pond",
+			Files: []*File{
+				{Name: "pond.txt", Language: "plaintext", Content: "Nobody remembers this pond."},
+			},
+			Visibility: VisibilityPublic,
+			Expires:    &expires,
+		}
+		assert.NilError(t, m.Insert(context.Background(), expired))
+
+		_, err := m.Get(context.Background(), expired.ID)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+		// The owner still gets the expired snippet, with its files.
+		snippet, err := m.GetOwned(context.Background(), expired.ID, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Title, "A forgotten pond")
+		assert.Equal(t, len(snippet.Files), 1)
+		assert.Equal(t, snippet.Expired(), true)
+
+		_, err = m.GetOwned(context.Background(), expired.ID, 2)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+	})
+}
+
+func TestSnippetModelLatest(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		tests := []struct {
+			name         string
+			filters      Filters
+			wantSnippets int
+			wantLastPage int
+		}{
+			{
+				// The private snippet in the test data must not be listed.
+				name:         "First page",
+				filters:      Filters{Page: 1, PageSize: 10},
+				wantSnippets: 1,
+				wantLastPage: 1,
+			},
+			{
+				name:         "Out of range page",
+				filters:      Filters{Page: 2, PageSize: 10},
+				wantSnippets: 0,
+				wantLastPage: 0,
+			},
+		}
+
+		for _, subtest := range tests {
+			t.Run(subtest.name, func(t *testing.T) {
+				db := newTestDB(t, dialect)
+				m := SnippetModel{DB: db, Dialect: dialect}
+
+				snippets, metadata, err := m.Latest(context.Background(), subtest.filters)
+
+				assert.NilError(t, err)
+				assert.Equal(t, len(snippets), subtest.wantSnippets)
+				assert.Equal(t, metadata.LastPage, subtest.wantLastPage)
+			})
+		}
+	})
+}
+
+func TestSnippetModelUpdateRevisions(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		snippet, err := m.Get(context.Background(), 1)
+		assert.NilError(t, err)
+
+		// Changing only the visibility doesn't record a new revision.
+		snippet.Visibility = VisibilityUnlisted
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+
+		revisions, err := m.Revisions(context.Background(), 1)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 2)
+
+		snippet.Files = snippet.Files[:1]
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+
+		revisions, err = m.Revisions(context.Background(), 1)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 3)
+		assert.Equal(t, revisions[0].Number, 3)
+		assert.Equal(t, len(revisions[0].Files), 1)
+		assert.Equal(t, revisions[0].Files[0].Name, "pond.txt")
+
+		revision, err := m.Revision(context.Background(), 1, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, revision.File("pond.txt").Content, "An old silent pond.")
+
+		_, err = m.Revision(context.Background(), 1, 4)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+	})
+}
+
+func TestSnippetModelUpdateBackfilledRevision(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		// The revisions backfilled by the migrations are encoded by the database,
+		// like MySQL does, with other spacing and key order than Go.
+		_, err := db.Exec(`UPDATE snippet_revisions SET files = '[{"content": "A world of dew, and within every dewdrop...", "language": "plaintext", "name": "dew.txt"}]' WHERE snippet_id = 2`)
+		assert.NilError(t, err)
+
+		snippet, err := m.Get(context.Background(), 2)
+		assert.NilError(t, err)
+
+		// Saving the snippet unchanged doesn't record a new revision.
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+
+		revisions, err := m.Revisions(context.Background(), 2)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 1)
+
+		snippet.Files[0].Content = "A world of dew."
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+
+		revisions, err = m.Revisions(context.Background(), 2)
+		assert.NilError(t, err)
+		assert.Equal(t, len(revisions), 2)
+	})
+}
+
+func TestSnippetModelUpdateSlug(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		snippet, err := m.Get(context.Background(), 1)
+		assert.NilError(t, err)
+		published := snippet.Slug
+
+		// The slug of a public snippet may have been published, so it changes
+		// when the snippet becomes unlisted.
+		snippet.Visibility = VisibilityUnlisted
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+		assert.Equal(t, snippet.Slug == published, false)
+		assert.Equal(t, len(snippet.Slug), SLUG_LENGTH)
+
+		_, err = m.GetBySlug(context.Background(), published)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+		unlisted, err := m.GetBySlug(context.Background(), snippet.Slug)
+		assert.NilError(t, err)
+		assert.Equal(t, unlisted.ID, 1)
+
+		// It's kept by the other updates.
+		snippet.Title = "An old silent pond, again"
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+		assert.Equal(t, snippet.Slug, unlisted.Slug)
+	})
+}
+
+func TestSnippetModelInsertFork(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		fork := &Snippet{
+			UserID: 1,
+			Title:  "An old silent pond",
+			Files: []*File{
+				{Name: "pond.txt", Language: "plaintext", Content: "An old silent pond, a frog jumps into the pond."},
+			},
+			Visibility: VisibilityPublic,
+			ForkedFrom: 1,
+		}
+		assert.NilError(t, m.Insert(context.Background(), fork))
+
+		snippet, err := m.Get(context.Background(), fork.ID)
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.ForkedFrom, 1)
+
+		source, err := m.Get(context.Background(), 1)
+		assert.NilError(t, err)
+		assert.Equal(t, source.Forks, 1)
+
+		// Deleting the source keeps the fork, but drops the reference.
+		assert.NilError(t, m.Delete(context.Background(), 1))
+
+		snippet, err = m.Get(context.Background(), fork.ID)
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.ForkedFrom, 0)
+	})
+}
+
+func TestSnippetModelTags(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		// The private snippet is also tagged haiku, but must not be listed.
+		snippets, metadata, err := m.ByTag(context.Background(), "haiku", Filters{Page: 1, PageSize: 10})
+		assert.NilError(t, err)
+		assert.Equal(t, len(snippets), 1)
+		assert.Equal(t, metadata.TotalRecords, 1)
+		assert.Equal(t, strings.Join(snippets[0].Tags, ","), "haiku,nature")
+
+		snippet := snippets[0]
+		snippet.Tags = []string{"frog", "haiku"}
+		assert.NilError(t, m.Update(context.Background(), snippet, 1))
+
+		snippet, err = m.Get(context.Background(), 1)
+		assert.NilError(t, err)
+		assert.Equal(t, strings.Join(snippet.Tags, ","), "frog,haiku")
+
+		snippets, _, err = m.ByTag(context.Background(), "nature", Filters{Page: 1, PageSize: 10})
+		assert.NilError(t, err)
+		assert.Equal(t, len(snippets), 0)
+	})
+}
+
+func TestSnippetModelBurn(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		// The private snippet never expires.
+		snippet, err := m.Get(context.Background(), 2)
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Expires == nil, true)
+
+		expires := time.Now().Add(time.Hour)
+		secret := &Snippet{
+			UserID: 1,
+			Title:  "A secret pond",
+			Files: []*File{
+				{Name: "pond.txt", Language: "plaintext", Content: "Nobody knows about this pond."},
+			},
+			Visibility:       VisibilityPublic,
+			Expires:          &expires,
+			BurnAfterReading: true,
+		}
+		assert.NilError(t, m.Insert(context.Background(), secret))
+
+		snippets, _, err := m.Latest(context.Background(), Filters{Page: 1, PageSize: 10})
+		assert.NilError(t, err)
+		assert.Equal(t, len(snippets), 1)
+
+		// Only burn-after-reading snippets are burnt, and only once.
+		assert.Equal(t, errors.Is(m.Burn(context.Background(), 1), ErrNoRecord), true)
+		assert.NilError(t, m.Burn(context.Background(), secret.ID))
+		assert.Equal(t, errors.Is(m.Burn(context.Background(), secret.ID), ErrNoRecord), true)
+
+		_, err = m.Get(context.Background(), secret.ID)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+	})
+}
+
+func TestSnippetModelDeleteExpired(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		expires := time.Now().Add(-time.Hour)
+		for i := 0; i < 3; i++ {
+			expired := &Snippet{
+				UserID: 1,
+				Title:  "A forgotten pond",
+				Files: []*File{
+					{Name: "pond.txt", Language: "plaintext", Content: "Nobody remembers this pond."},
+				},
+				Visibility: VisibilityPublic,
+				Expires:    &expires,
+			}
+			assert.NilError(t, m.Insert(context.Background(), expired))
+		}
+
+		deleted, err := m.DeleteExpired(context.Background(), 2)
+		assert.NilError(t, err)
+		assert.Equal(t, deleted, 2)
+
+		deleted, err = m.DeleteExpired(context.Background(), 2)
+		assert.NilError(t, err)
+		assert.Equal(t, deleted, 1)
+
+		// The snippets which haven't expired, or never expire, are kept.
+		_, err = m.Get(context.Background(), 1)
+		assert.NilError(t, err)
+		_, err = m.Get(context.Background(), 2)
+		assert.NilError(t, err)
+	})
+}
+
+func TestSnippetModelExtend(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := SnippetModel{DB: db, Dialect: dialect}
+
+		expires := time.Now().Add(-time.Hour)
+		expired := &Snippet{
+			UserID: 1,
+			Title:  "A forgotten pond",
+			Files: []*File{
+				{Name: "pond.txt", Language: "plaintext", Content: "Nobody remembers this pond."},
+			},
+			Visibility: VisibilityPublic,
+			Expires:    &expires,
+		}
+		assert.NilError(t, m.Insert(context.Background(), expired))
+
+		// Expired snippets are renewed from now on.
+		assert.NilError(t, m.Extend(context.Background(), expired.ID, 1, 24*time.Hour))
+
+		snippet, err := m.Get(context.Background(), expired.ID)
+		assert.NilError(t, err)
+		assert.Equal(t, snippet.Expires.After(time.Now().Add(23*time.Hour)), true)
+
+		// Only the owner can extend a snippet, and only if it expires at all.
+		assert.Equal(t, errors.Is(m.Extend(context.Background(), expired.ID, 2, 24*time.Hour), ErrNoRecord), true)
+		assert.Equal(t, errors.Is(m.Extend(context.Background(), 2, 1, 24*time.Hour), ErrNoRecord), true)
+	})
+}
+
+func TestSnippetModelSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		terms   string
+		wantIDs []int
+	}{
+		{
+			name:    "Title",
+			terms:   "pond",
+			wantIDs: []int{1},
+		},
+		{
+			name:    "File content",
+			terms:   "frog",
+			wantIDs: []int{1},
+		},
+		{
+			name:  "Private snippet",
+			terms: "dewdrop",
+		},
+		{
+			name:  "No match",
+			terms: "toad",
+		},
+	}
+
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		for _, subtest := range tests {
+			t.Run(subtest.name, func(t *testing.T) {
+				db := newTestDB(t, dialect)
+				m := SnippetModel{DB: db, Dialect: dialect}
+
+				snippets, metadata, err := m.Search(context.Background(), subtest.terms, Filters{Page: 1, PageSize: 10})
+				assert.NilError(t, err)
+
+				assert.Equal(t, len(snippets), len(subtest.wantIDs))
+				assert.Equal(t, metadata.TotalRecords, len(subtest.wantIDs))
+				for i, id := range subtest.wantIDs {
+					assert.Equal(t, snippets[i].ID, id)
+					assert.Equal(t, strings.Join(snippets[i].Tags, ","), "haiku,nature")
+				}
+			})
+		}
+	})
+}
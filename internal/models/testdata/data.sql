@@ -0,0 +1,37 @@
+INSERT INTO users (name, email, hashed_password, created) VALUES (
+    'Alice Jones',
+    'alice@example.com',
+    '$2a$12$NuTjWXm3KKntReFwyBVHyuf/to.HEwTy.eS206TNfkGfr6HzGJSWG',
+    '2022-01-01 10:00:00'
+);
+
+INSERT INTO snippets (slug, user_id, title, created, expires) VALUES (
+    'fa6mQ2ZcLm1ZxQbO',
+    1,
+    'An old silent pond',
+    '2022-01-01 10:00:00',
+    '2099-01-01 10:00:00'
+);
+
+INSERT INTO snippets (slug, user_id, title, visibility, created, expires) VALUES (
+    'Zq4wE6tRy8UiOp0a',
+    1,
+    'A world of dew',
+    'private',
+    '2022-01-02 10:00:00',
+    NULL
+);
+
+INSERT INTO snippet_files (snippet_id, position, filename, language, content) VALUES
+    (1, 0, 'pond.txt', 'plaintext', 'An old silent pond...'),
+    (1, 1, 'frog.txt', 'plaintext', 'A frog jumps into the pond, splash! Silence again.'),
+    (2, 0, 'dew.txt', 'plaintext', 'A world of dew, and within every dewdrop...');
+
+INSERT INTO snippet_revisions (snippet_id, number, user_id, title, files, created) VALUES
+    (1, 1, 1, 'An old silent pond', '[{"name":"pond.txt","language":"plaintext","content":"An old silent pond."}]', '2022-01-01 10:00:00'),
+    (1, 2, 1, 'An old silent pond', '[{"name":"pond.txt","language":"plaintext","content":"An old silent pond..."},{"name":"frog.txt","language":"plaintext","content":"A frog jumps into the pond, splash! Silence again."}]', '2022-01-01 11:00:00'),
+    (2, 1, 1, 'A world of dew', '[{"name":"dew.txt","language":"plaintext","content":"A world of dew, and within every dewdrop..."}]', '2022-01-02 10:00:00');
+
+INSERT INTO tags (name) VALUES ('haiku'), ('nature');
+
+INSERT INTO snippet_tags (snippet_id, tag_id) VALUES (1, 1), (1, 2), (2, 1);
@@ -1,49 +1,103 @@
 package models
 
 import (
+	"context"
 	"database/sql"
+	"math"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
-func newTestDB(t *testing.T) *sql.DB {
-	// Establish a sql.DB connection pool for our test database. Because our
-	// setup and teardown scripts contains multiple SQL statements, we need
-	// to use the "multiStatements=true" parameter in our DSN. This instructs
-	// our MySQL database driver to support executing multiple SQL statements
-	// in one db.Exec() call.
-	db, err := sql.Open("mysql", "test_web:pass1234@/test_snippetbox?parseTime=true&multiStatements=true")
-	if err != nil {
-		t.Fatal(err)
+// forEachBackend runs test against every database backend, which makes the
+// model tests the conformance suite that all the dialects must pass. SQLite
+// always runs, in a temporary file. MySQL needs the test database described in
+// the README and is skipped in short mode, while PostgreSQL only runs if the
+// TEST_POSTGRES_DSN environment variable points to a test database.
+func forEachBackend(t *testing.T, test func(t *testing.T, dialect Dialect)) {
+	for _, driver := range Drivers {
+		dialect, err := DialectFor(driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(driver, func(t *testing.T) {
+			test(t, dialect)
+		})
 	}
+}
 
-	script, err := os.ReadFile("./testdata/setup.sql")
-	if err != nil {
+// testDSN returns the data source name of the test database of the given
+// backend, or skips the test if there is none.
+func testDSN(t *testing.T, dialect Dialect) string {
+	switch dialect.Name() {
+	case DriverMySQL:
+		if testing.Short() {
+			t.Skip("models: skipping MySQL integration test")
+		}
+		return "test_web:pass1234@/test_snippetbox?parseTime=true"
+	case DriverPostgres:
+		dsn := os.Getenv("TEST_POSTGRES_DSN")
+		if dsn == "" {
+			t.Skip("models: skipping PostgreSQL integration test, TEST_POSTGRES_DSN isn't set")
+		}
+		return dsn
+	default:
+		path := filepath.Join(t.TempDir(), "test_snippetbox.db")
+		return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate"
+	}
+}
+
+// newTestDB returns a connection pool to a fresh test database of the given
+// backend, with the schema of the migrations and the data of the testdata
+// directory.
+func newTestDB(t *testing.T, dialect Dialect) *sql.DB {
+	db, migrator := openTestDB(t, dialect)
+
+	if _, err := migrator.Up(context.Background()); err != nil {
 		t.Fatal(err)
 	}
+	execScript(t, db, "data.sql")
+
+	return db
+}
 
-	_, err = db.Exec(string(script))
+// openTestDB returns a connection pool to the empty test database of the given
+// backend, and a migrator for it.
+func openTestDB(t *testing.T, dialect Dialect) (*sql.DB, *Migrator) {
+	db, err := sql.Open(dialect.DriverName(), testDSN(t, dialect))
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	migrator := &Migrator{DB: db, Dialect: dialect}
+
 	// Use the t.Cleanup() to register a function *which will automatically be
-	// called by Go when the current test (or sub-test) which calls newTestDB()
-	// has finished*. In this function we read and execute the teardown script,
-	// and close the database connection pool.
+	// called by Go when the current test (or sub-test) which calls openTestDB()
+	// has finished*. In this function we revert all the migrations, and close
+	// the database connection pool.
 	t.Cleanup(func() {
-		script, err := os.ReadFile("./testdata/teardown.sql")
-		if err != nil {
+		if _, err := migrator.Down(context.Background(), math.MaxInt); err != nil {
 			t.Fatal(err)
 		}
-
-		_, err = db.Exec(string(script))
-		if err != nil {
+		if _, err := db.Exec("DROP TABLE schema_migrations"); err != nil {
 			t.Fatal(err)
 		}
-
 		db.Close()
 	})
 
-	return db
+	return db, migrator
+}
+
+// execScript runs the statements of a script of the testdata directory.
+func execScript(t *testing.T, db *sql.DB, file string) {
+	script, err := os.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, statement := range splitStatements(string(script)) {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+	}
 }
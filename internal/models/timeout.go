@@ -0,0 +1,8 @@
+package models
+
+import "time"
+
+// queryTimeout bounds the time every model method may spend in the database,
+// whatever the deadline of the context it's given, so that a slow query is
+// cancelled rather than left running after the response has been given up on.
+const queryTimeout = 3 * time.Second
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Scopes which can be granted to a personal API token.
+const (
+	ScopeSnippetsRead  = "snippets:read"
+	ScopeSnippetsWrite = "snippets:write"
+	ScopeAccountRead   = "account:read"
+)
+
+var Scopes = []string{ScopeSnippetsRead, ScopeSnippetsWrite, ScopeAccountRead}
+
+// TOKEN_LENGTH is the length of the base32 encoded plaintext of a token.
+const TOKEN_LENGTH = 52
+
+type TokenModelInterface interface {
+	New(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (*Token, error)
+	GetForToken(ctx context.Context, plaintext string) (*Token, error)
+	AllForUser(ctx context.Context, userID int) ([]*Token, error)
+	Delete(ctx context.Context, id, userID int) error
+}
+
+// Token is a personal API token. Only the SHA-256 hash of a token is stored in
+// the database, so the plaintext is only known right after the token is created.
+type Token struct {
+	ID        int
+	UserID    int
+	Name      string
+	Plaintext string
+	Hash      []byte
+	Scopes    []string
+	Created   time.Time
+	Expires   time.Time
+}
+
+// HasScope reports whether the token has been granted the given scope.
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenModel struct {
+	DB      *sql.DB
+	Dialect Dialect
+}
+
+func (m *TokenModel) db() conn {
+	return conn{m.DB, m.Dialect}
+}
+
+func generateToken(userID int, name string, scopes []string, ttl time.Duration) (*Token, error) {
+	token := &Token{
+		UserID:  userID,
+		Name:    name,
+		Scopes:  scopes,
+		Created: time.Now().UTC(),
+		Expires: time.Now().UTC().Add(ttl),
+	}
+
+	randomBytes := make([]byte, 32)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, err
+	}
+
+	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	hash := sha256.Sum256([]byte(token.Plaintext))
+	token.Hash = hash[:]
+
+	return token, nil
+}
+
+func (m *TokenModel) New(ctx context.Context, userID int, name string, scopes []string, ttl time.Duration) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	token, err := generateToken(userID, name, scopes, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := `INSERT INTO tokens (user_id, name, hash, scopes, created, expires)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	id, err := m.Dialect.insertID(ctx, m.db(), stmt, token.UserID, token.Name, token.Hash, strings.Join(token.Scopes, ","), token.Created, token.Expires)
+	if err != nil {
+		return nil, err
+	}
+	token.ID = id
+
+	return token, nil
+}
+
+// GetForToken returns the non-expired token matching the given plaintext, or
+// ErrNoRecord if there isn't one.
+func (m *TokenModel) GetForToken(ctx context.Context, plaintext string) (*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	var scopes string
+	token := &Token{}
+
+	stmt := `SELECT id, user_id, name, hash, scopes, created, expires FROM tokens
+	WHERE hash = ? AND expires > ?`
+	err := m.db().QueryRowContext(ctx, stmt, hash[:], time.Now().UTC()).Scan(&token.ID, &token.UserID, &token.Name, &token.Hash, &scopes, &token.Created, &token.Expires)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrNoRecord
+		default:
+			return nil, err
+		}
+	}
+	token.Scopes = strings.Split(scopes, ",")
+
+	return token, nil
+}
+
+// AllForUser returns every token (including expired ones) of the given user.
+func (m *TokenModel) AllForUser(ctx context.Context, userID int) ([]*Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	stmt := `SELECT id, user_id, name, scopes, created, expires FROM tokens
+	WHERE user_id = ? ORDER BY created DESC, id DESC`
+	rows, err := m.db().QueryContext(ctx, stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*Token{}
+	for rows.Next() {
+		var scopes string
+		token := &Token{}
+		if err := rows.Scan(&token.ID, &token.UserID, &token.Name, &scopes, &token.Created, &token.Expires); err != nil {
+			return nil, err
+		}
+		token.Scopes = strings.Split(scopes, ",")
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Delete revokes a token. The user ID is part of the query so that users can
+// only ever revoke their own tokens.
+func (m *TokenModel) Delete(ctx context.Context, id, userID int) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	stmt := `DELETE FROM tokens WHERE id = ? AND user_id = ?`
+	result, err := m.db().ExecContext(ctx, stmt, id, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNoRecord
+	}
+	return nil
+}
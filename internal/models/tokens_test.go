@@ -0,0 +1,46 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladComan0/go-snippets/internal/assert"
+)
+
+func TestTokenModel(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := TokenModel{DB: db, Dialect: dialect}
+		ctx := context.Background()
+
+		token, err := m.New(ctx, 1, "CI", []string{ScopeSnippetsWrite}, time.Hour)
+		assert.NilError(t, err)
+		assert.Equal(t, token.ID, 1)
+
+		expired, err := m.New(ctx, 1, "Old", []string{ScopeAccountRead}, -time.Hour)
+		assert.NilError(t, err)
+		assert.Equal(t, expired.ID, 2)
+
+		found, err := m.GetForToken(ctx, token.Plaintext)
+		assert.NilError(t, err)
+		assert.Equal(t, found.ID, token.ID)
+		assert.Equal(t, found.Name, "CI")
+		assert.Equal(t, found.Scopes[0], ScopeSnippetsWrite)
+
+		_, err = m.GetForToken(ctx, expired.Plaintext)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+
+		tokens, err := m.AllForUser(ctx, 1)
+		assert.NilError(t, err)
+		assert.Equal(t, len(tokens), 2)
+
+		// Users can only revoke their own tokens.
+		assert.Equal(t, errors.Is(m.Delete(ctx, token.ID, 2), ErrNoRecord), true)
+		assert.NilError(t, m.Delete(ctx, token.ID, 1))
+
+		_, err = m.GetForToken(ctx, token.Plaintext)
+		assert.Equal(t, errors.Is(err, ErrNoRecord), true)
+	})
+}
@@ -1,66 +1,110 @@
 package models
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"errors"
-	"strings"
 	"time"
 
-	"github.com/go-sql-driver/mysql"
 	"golang.org/x/crypto/bcrypt"
 )
 
-const (
-	COST          = 12   // 2^12 bcrypt iterations used to generate the password hash (4-31)
-	ERR_DUP_ENTRY = 1062 // MySQL Error number for duplicate entries
-	CONSTRAINT    = "user_uc_email"
-)
+const COST = 12 // 2^12 bcrypt iterations used to generate the password hash (4-31)
+
+// LOCKED_PASSWORD is stored instead of the password hash of the accounts which
+// can't log in, such as the Anonymous owner of the snippets created before
+// snippets had owners. It is never a bcrypt hash, so no password matches it.
+const LOCKED_PASSWORD = "!"
 
 type UserModelInterface interface {
-	Insert(name, email, password string) error
-	Authenticate(email, password string) (int, error)
-	Exists(id int) (bool, error)
-	Get(id int) (*User, error)
-	UpdatePassword(id int, currentPassword, newPassword string) error
+	Insert(ctx context.Context, name, email, password string) error
+	Authenticate(ctx context.Context, email, password string) (int, error)
+	Exists(ctx context.Context, id int) (bool, error)
+	Get(ctx context.Context, id int) (*User, error)
+	UpdatePassword(ctx context.Context, id int, currentPassword, newPassword string) error
 }
 
 type User struct {
-	ID             int
-	Name           string
-	Email          string
-	HashedPassword []byte
-	Created        time.Time
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Email          string    `json:"email"`
+	HashedPassword []byte    `json:"-"`
+	Created        time.Time `json:"created"`
 }
 
 type UserModel struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
+	// ObservePasswordHash, if set, is called with the time taken by every bcrypt
+	// operation ("generate" or "compare"), which dominates the cost of signups
+	// and logins.
+	ObservePasswordHash func(operation string, duration time.Duration)
+}
+
+func (m *UserModel) db() conn {
+	return conn{m.DB, m.Dialect}
 }
 
-func (m *UserModel) Insert(name, email, password string) error {
+// generateFromPassword is bcrypt.GenerateFromPassword, timed.
+func (m *UserModel) generateFromPassword(password string) ([]byte, error) {
+	start := time.Now()
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), COST)
+	if m.ObservePasswordHash != nil {
+		m.ObservePasswordHash("generate", time.Since(start))
+	}
+	return hashedPassword, err
+}
+
+// compareHashAndPassword is bcrypt.CompareHashAndPassword, timed. The locked
+// accounts are refused without calling bcrypt. Their hash is trimmed, since
+// PostgreSQL pads the CHAR(60) column with spaces.
+func (m *UserModel) compareHashAndPassword(hashedPassword []byte, password string) error {
+	if string(bytes.TrimRight(hashedPassword, " ")) == LOCKED_PASSWORD {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+
+	start := time.Now()
+	err := bcrypt.CompareHashAndPassword(hashedPassword, []byte(password))
+	if m.ObservePasswordHash != nil {
+		m.ObservePasswordHash("compare", time.Since(start))
+	}
+	return err
+}
+
+func (m *UserModel) Insert(ctx context.Context, name, email, password string) error {
+	hashedPassword, err := m.generateFromPassword(password)
 	if err != nil {
 		return err
 	}
+
+	// The query timeout doesn't include the hashing time.
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	stmt := `INSERT INTO users (name, email, hashed_password, created)
-	VALUES (?, ?, ?, UTC_TIMESTAMP())`
-	_, err = m.DB.Exec(stmt, name, email, hashedPassword)
+	VALUES (?, ?, ?, ?)`
+	_, err = m.db().ExecContext(ctx, stmt, name, email, hashedPassword, time.Now().UTC())
 	if err != nil {
-		// Validate also the duplicate email error
-		if validateEmailError := validateDuplicateEmail(err); validateEmailError != nil {
-			return validateEmailError
+		// The email is the only unique column of the users table.
+		if m.Dialect.isUniqueViolation(err) {
+			return ErrDuplicateEmail
 		}
 		return err
 	}
 	return nil
 }
 
-func (m *UserModel) Authenticate(email, password string) (int, error) {
+func (m *UserModel) Authenticate(ctx context.Context, email, password string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	var (
 		id             int
 		hashedPassword []byte
 	)
 	stmt := "SELECT id, hashed_password FROM users WHERE email = ?"
-	if err := m.DB.QueryRow(stmt, email).Scan(&id, &hashedPassword); err != nil {
+	if err := m.db().QueryRowContext(ctx, stmt, email).Scan(&id, &hashedPassword); err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return 0, ErrInvalidCredentials
@@ -69,7 +113,7 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 		}
 	}
 
-	if err := bcrypt.CompareHashAndPassword(hashedPassword, []byte(password)); err != nil {
+	if err := m.compareHashAndPassword(hashedPassword, password); err != nil {
 		switch {
 		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
 			return 0, ErrInvalidCredentials
@@ -81,20 +125,26 @@ func (m *UserModel) Authenticate(email, password string) (int, error) {
 	return id, nil
 }
 
-func (m *UserModel) Exists(id int) (bool, error) {
+func (m *UserModel) Exists(ctx context.Context, id int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	var exists bool
 
 	stmt := "SELECT EXISTS(SELECT true FROM users WHERE id = ?)"
-	err := m.DB.QueryRow(stmt, id).Scan(&exists)
+	err := m.db().QueryRowContext(ctx, stmt, id).Scan(&exists)
 
 	return exists, err
 }
 
-func (m *UserModel) Get(id int) (*User, error) {
+func (m *UserModel) Get(ctx context.Context, id int) (*User, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	user := &User{}
 
 	stmt := "SELECT id, name, email, created FROM users WHERE id = ?"
-	if err := m.DB.QueryRow(stmt, id).Scan(&user.ID, &user.Name, &user.Email, &user.Created); err != nil {
+	if err := m.db().QueryRowContext(ctx, stmt, id).Scan(&user.ID, &user.Name, &user.Email, &user.Created); err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
 			return nil, ErrNoRecord
@@ -106,15 +156,19 @@ func (m *UserModel) Get(id int) (*User, error) {
 	return user, nil
 }
 
-func (m *UserModel) UpdatePassword(id int, currentPassword, newPassword string) error {
+func (m *UserModel) UpdatePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
 	var hashedCurrentPassword []byte
 
+	// Each query gets its own timeout, which doesn't include the hashing time.
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	stmt := "SELECT hashed_password FROM users WHERE id = ?"
-	if err := m.DB.QueryRow(stmt, id).Scan(&hashedCurrentPassword); err != nil {
+	if err := m.db().QueryRowContext(queryCtx, stmt, id).Scan(&hashedCurrentPassword); err != nil {
 		return err
 	}
 
-	if err := bcrypt.CompareHashAndPassword(hashedCurrentPassword, []byte(currentPassword)); err != nil {
+	if err := m.compareHashAndPassword(hashedCurrentPassword, currentPassword); err != nil {
 		switch {
 		case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
 			return ErrInvalidCredentials
@@ -123,29 +177,22 @@ func (m *UserModel) UpdatePassword(id int, currentPassword, newPassword string)
 		}
 	}
 
-	if err := bcrypt.CompareHashAndPassword(hashedCurrentPassword, []byte(newPassword)); err == nil {
+	if err := m.compareHashAndPassword(hashedCurrentPassword, newPassword); err == nil {
 		return ErrSamePassword
 	}
 
-	hashedNewPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), COST)
+	hashedNewPassword, err := m.generateFromPassword(newPassword)
 	if err != nil {
 		return err
 	}
 
+	queryCtx, cancel = context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	stmt = "UPDATE users SET hashed_password = ? WHERE id = ?"
-	if _, err := m.DB.Exec(stmt, hashedNewPassword, id); err != nil {
+	if _, err := m.db().ExecContext(queryCtx, stmt, hashedNewPassword, id); err != nil {
 		return err
 	}
 
 	return nil
 }
-
-func validateDuplicateEmail(err error) error {
-	var mySQLError *mysql.MySQLError
-	if errors.As(err, &mySQLError) {
-		if mySQLError.Number == ERR_DUP_ENTRY && strings.Contains(mySQLError.Message, CONSTRAINT) {
-			return ErrDuplicateEmail
-		}
-	}
-	return nil
-}
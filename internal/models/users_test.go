@@ -1,47 +1,123 @@
 package models
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/vladComan0/go-snippets/internal/assert"
 )
 
 func TestUserModelExists(t *testing.T) {
-	if testing.Short() {
-		t.Skip("models: skipping integration test")
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		tests := []struct {
+			name   string
+			userID int
+			want   bool
+		}{
+			{
+				name:   "Valid ID",
+				userID: 1,
+				want:   true,
+			},
+			{
+				name:   "Non-existent ID",
+				userID: 5,
+				want:   false,
+			},
+			{
+				name:   "Zero ID",
+				userID: 0,
+				want:   false,
+			},
+		}
+
+		for _, subtest := range tests {
+			t.Run(subtest.name, func(t *testing.T) {
+				db := newTestDB(t, dialect)
+				m := UserModel{DB: db, Dialect: dialect}
+
+				exists, err := m.Exists(context.Background(), subtest.userID)
+
+				assert.Equal(t, exists, subtest.want)
+				assert.NilError(t, err)
+			})
+		}
+	})
+}
+
+func TestUserModelObservePasswordHash(t *testing.T) {
+	var operations []string
+	m := UserModel{
+		ObservePasswordHash: func(operation string, duration time.Duration) {
+			operations = append(operations, operation)
+		},
 	}
 
+	hashedPassword, err := m.generateFromPassword("pa$$word")
+	assert.NilError(t, err)
+	assert.NilError(t, m.compareHashAndPassword(hashedPassword, "pa$$word"))
+
+	assert.Equal(t, strings.Join(operations, ","), "generate,compare")
+}
+
+func TestUserModelInsert(t *testing.T) {
 	tests := []struct {
-		name   string
-		userID int
-		want   bool
+		name    string
+		email   string
+		wantErr error
 	}{
 		{
-			name:   "Valid ID",
-			userID: 1,
-			want:   true,
+			name:  "Valid email",
+			email: "bob@example.com",
 		},
 		{
-			name:   "Non-existent ID",
-			userID: 5,
-			want:   false,
-		},
-		{
-			name:   "Zero ID",
-			userID: 0,
-			want:   false,
+			name:    "Duplicate email",
+			email:   "alice@example.com",
+			wantErr: ErrDuplicateEmail,
 		},
 	}
 
-	for _, subtest := range tests {
-		t.Run(subtest.name, func(t *testing.T) {
-			db := newTestDB(t)
-			m := UserModel{db}
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		for _, subtest := range tests {
+			t.Run(subtest.name, func(t *testing.T) {
+				db := newTestDB(t, dialect)
+				m := UserModel{DB: db, Dialect: dialect}
 
-			exists, err := m.Exists(subtest.userID)
+				err := m.Insert(context.Background(), "Bob", subtest.email, "pa$$word")
+				assert.Equal(t, errors.Is(err, subtest.wantErr), true)
+				if subtest.wantErr != nil {
+					return
+				}
 
-			assert.Equal(t, exists, subtest.want)
-			assert.NilError(t, err)
-		})
-	}
+				id, err := m.Authenticate(context.Background(), subtest.email, "pa$$word")
+				assert.NilError(t, err)
+				assert.Equal(t, id, 2)
+
+				_, err = m.Authenticate(context.Background(), subtest.email, "wrong")
+				assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+			})
+		}
+	})
+}
+
+func TestUserModelAuthenticateLocked(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, dialect Dialect) {
+		db := newTestDB(t, dialect)
+		m := UserModel{DB: db, Dialect: dialect}
+
+		_, err := db.Exec(`INSERT INTO users (name, email, hashed_password, created)
+		VALUES ('Anonymous', 'anonymous@snippetbox.invalid', '` + LOCKED_PASSWORD + `', '2022-01-01 10:00:00')`)
+		assert.NilError(t, err)
+
+		for _, password := range []string{"", LOCKED_PASSWORD, "pa$$word"} {
+			_, err = m.Authenticate(context.Background(), "anonymous@snippetbox.invalid", password)
+			assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+		}
+
+		err = m.UpdatePassword(context.Background(), 2, LOCKED_PASSWORD, "pa$$word")
+		assert.Equal(t, errors.Is(err, ErrInvalidCredentials), true)
+	})
 }
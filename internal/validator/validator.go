@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"cmp"
 	"regexp"
 	"strings"
 	"unicode/utf8"
@@ -67,6 +68,11 @@ func PermittedValue[T comparable](value T, permittedValues ...T) bool {
 	return false
 }
 
+// Between returns true if a value lies within the inclusive range [min, max].
+func Between[T cmp.Ordered](value, min, max T) bool {
+	return value >= min && value <= max
+}
+
 func MinChars(value string, n int) bool {
 	return utf8.RuneCountInString(value) >= n
 }
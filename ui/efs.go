@@ -2,5 +2,7 @@ package ui
 
 import "embed"
 
+//go:generate go run ../internal/highlight/gencss -o static/css/highlight.css
+
 //go:embed "html" "static"
 var Files embed.FS
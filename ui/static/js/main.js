@@ -5,4 +5,53 @@ for (var i = 0; i < navLinks.length; i++) {
 		link.classList.add("live");
 		break;
 	}
-}
\ No newline at end of file
+}
+
+// Let users add and remove the files of the snippet create and edit forms. The
+// fields are renumbered after every change, so that the browser keeps posting a
+// regular form (files[0].name, files[1].name...) along with its CSRF token.
+var fileLists = document.querySelectorAll("div.files");
+for (var i = 0; i < fileLists.length; i++) {
+	setUpFiles(fileLists[i]);
+}
+
+function setUpFiles(list) {
+	var maxFiles = parseInt(list.dataset.maxFiles, 10);
+	var addButton = list.querySelector("button.add-file");
+
+	function renumber() {
+		var files = list.querySelectorAll("fieldset.file");
+		for (var i = 0; i < files.length; i++) {
+			var fields = files[i].querySelectorAll("[name^='files[']");
+			for (var j = 0; j < fields.length; j++) {
+				fields[j].name = fields[j].name.replace(/^files\[\d+\]/, "files[" + i + "]");
+			}
+			files[i].querySelector("button.remove-file").hidden = files.length == 1;
+		}
+		addButton.hidden = files.length >= maxFiles;
+	}
+
+	list.addEventListener("click", function (event) {
+		var button = event.target;
+		if (button.classList.contains("remove-file")) {
+			button.closest("fieldset.file").remove();
+			renumber();
+		} else if (button.classList.contains("add-file")) {
+			var files = list.querySelectorAll("fieldset.file");
+			var file = files[files.length - 1].cloneNode(true);
+			var fields = file.querySelectorAll("input, textarea");
+			for (var i = 0; i < fields.length; i++) {
+				fields[i].value = "";
+			}
+			var errors = file.querySelectorAll("label.error");
+			for (var i = 0; i < errors.length; i++) {
+				errors[i].remove();
+			}
+			list.insertBefore(file, addButton);
+			renumber();
+			file.querySelector("input").focus();
+		}
+	});
+
+	renumber();
+}